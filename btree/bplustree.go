@@ -0,0 +1,693 @@
+package btree
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// BPlusTree is a B+tree, a variant of Tree that keeps its values only in the
+// leaves. The other pages hold copies of keys that separate their children, and
+// each leaf links to the leaves either side of it, so iterating over a range
+// walks along the leaves without going back up the tree.
+//
+// A tree of order k has between k and 2k entries in each leaf and between k
+// and 2k separator keys in each of the other pages, except the root, which may
+// hold fewer.
+//
+// It has the same methods as Tree for finding, iterating over and navigating
+// between entries, but not Rank, Select, CountRange or Clone. Those need each
+// page to count the entries below it and to be copied before it is changed,
+// which would give up the cheap splits and merges that suit a B+tree to
+// workloads that mostly scan.
+//
+// The zero value is not ready to use. Create one with NewBPlus or one of its
+// variants.
+type BPlusTree[K any, V any] struct {
+	root       *bplusPage[K, V]
+	numEntries int
+	order      order
+	compare    func(a, b K) int
+}
+
+// bplusPage is a page of a BPlusTree. A leaf has a value for each key and links
+// to its neighbours, and any other page has a child either side of each key.
+// Child i holds the keys less than keys[i] and child i+1 those greater than or
+// equal to it.
+type bplusPage[K any, V any] struct {
+	keys     items[K]
+	values   items[V]
+	children items[*bplusPage[K, V]]
+
+	prev, next *bplusPage[K, V]
+}
+
+func NewBPlus[K constraints.Ordered, V any]() BPlusTree[K, V] {
+	return NewBPlusWithOrder[K, V](defaultK)
+}
+
+// NewBPlusWithOrder returns an empty B+tree of order k.
+func NewBPlusWithOrder[K constraints.Ordered, V any](k int) BPlusTree[K, V] {
+	return NewBPlusFuncWithOrder[K, V](k, cmp.Compare[K])
+}
+
+// NewBPlusFunc returns an empty B+tree that orders its keys using compare. See
+// NewFunc.
+func NewBPlusFunc[K any, V any](compare func(a, b K) int) BPlusTree[K, V] {
+	return NewBPlusFuncWithOrder[K, V](defaultK, compare)
+}
+
+// NewBPlusFuncWithOrder returns an empty B+tree of order k that orders its keys
+// using compare.
+func NewBPlusFuncWithOrder[K any, V any](k int, compare func(a, b K) int) BPlusTree[K, V] {
+	return BPlusTree[K, V]{order: newOrder(k), compare: compare}
+}
+
+func newBPlusLeaf[K any, V any](o order) *bplusPage[K, V] {
+	return &bplusPage[K, V]{
+		keys:   make(items[K], 0, o.maxEntries+1),
+		values: make(items[V], 0, o.maxEntries+1),
+	}
+}
+
+func newBPlusInterior[K any, V any](o order) *bplusPage[K, V] {
+	return &bplusPage[K, V]{
+		keys:     make(items[K], 0, o.maxEntries+1),
+		children: make(items[*bplusPage[K, V]], 0, o.maxChildren+1),
+	}
+}
+
+func (p *bplusPage[K, V]) isLeaf() bool {
+	return p.children == nil
+}
+
+// childIndex returns the index of the child of p whose keys include key.
+func (p *bplusPage[K, V]) childIndex(key K, compare func(a, b K) int) int {
+	i, found := searchKeys(p.keys, key, compare)
+	if found {
+		return i + 1
+	}
+	return i
+}
+
+func searchKeys[K any](keys []K, key K, compare func(a, b K) int) (int, bool) {
+	lo, hi := 0, len(keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch c := compare(keys[mid], key); {
+		case c < 0:
+			lo = mid + 1
+		case c > 0:
+			hi = mid
+		default:
+			return mid, true
+		}
+	}
+
+	return lo, false
+}
+
+func (t *BPlusTree[K, V]) Len() int {
+	return t.numEntries
+}
+
+// Insert sets the value of key, adding an entry for it if there is none.
+func (t *BPlusTree[K, V]) Insert(key K, value V) {
+	if t.root == nil {
+		t.root = newBPlusLeaf[K, V](t.order)
+	}
+
+	var buf [maxPathLen]bplusFrame[K, V]
+	leaf, path := t.descend(key, buf[:0])
+
+	i, found := searchKeys(leaf.keys, key, t.compare)
+	if found {
+		leaf.values[i] = value
+		return
+	}
+
+	leaf.keys.insertAt(i, key)
+	leaf.values.insertAt(i, value)
+	t.numEntries += 1
+
+	for p, d := leaf, len(path)-1; len(p.keys) > t.order.maxEntries; d -= 1 {
+		separator, right := t.split(p)
+
+		if d < 0 {
+			root := newBPlusInterior[K, V](t.order)
+			root.keys.add(separator)
+			root.children = append(root.children, p, right)
+			t.root = root
+			return
+		}
+
+		parent := path[d]
+		parent.page.keys.insertAt(parent.index, separator)
+		parent.page.children.insertAt(parent.index+1, right)
+		p = parent.page
+	}
+}
+
+// split moves the upper half of an overflowing page into a new page to its
+// right, and returns the key that separates them in their parent. A leaf keeps
+// a copy of the separator as its first key, whereas any other page moves it up
+// to the parent.
+func (t *BPlusTree[K, V]) split(p *bplusPage[K, V]) (K, *bplusPage[K, V]) {
+	mid := t.order.minEntries
+
+	if p.isLeaf() {
+		right := newBPlusLeaf[K, V](t.order)
+		right.keys = append(right.keys, p.keys[mid:]...)
+		right.values = append(right.values, p.values[mid:]...)
+		clear(p.keys[mid:])
+		clear(p.values[mid:])
+		p.keys, p.values = p.keys[:mid], p.values[:mid]
+
+		right.prev, right.next = p, p.next
+		if p.next != nil {
+			p.next.prev = right
+		}
+		p.next = right
+
+		return right.keys[0], right
+	}
+
+	right := newBPlusInterior[K, V](t.order)
+	separator := p.keys[mid]
+	right.keys = append(right.keys, p.keys[mid+1:]...)
+	right.children = append(right.children, p.children[mid+1:]...)
+	clear(p.keys[mid:])
+	clear(p.children[mid+1:])
+	p.keys, p.children = p.keys[:mid], p.children[:mid+1]
+
+	return separator, right
+}
+
+// Find returns the value of key.
+func (t *BPlusTree[K, V]) Find(key K) (V, bool) {
+	var zeroValue V
+	if t.root == nil {
+		return zeroValue, false
+	}
+
+	leaf, _ := t.descend(key, nil)
+	i, found := searchKeys(leaf.keys, key, t.compare)
+	if !found {
+		return zeroValue, false
+	}
+
+	return leaf.values[i], true
+}
+
+// Delete removes the entry with key and returns its value. The separators in
+// the pages above are left alone, since a separator need not be the key of an
+// entry to divide the keys either side of it.
+func (t *BPlusTree[K, V]) Delete(key K) (V, bool) {
+	var zeroValue V
+	if t.root == nil {
+		return zeroValue, false
+	}
+
+	var buf [maxPathLen]bplusFrame[K, V]
+	leaf, path := t.descend(key, buf[:0])
+
+	i, found := searchKeys(leaf.keys, key, t.compare)
+	if !found {
+		return zeroValue, false
+	}
+
+	leaf.keys.removeAt(i)
+	value := leaf.values.removeAt(i)
+	t.numEntries -= 1
+
+	t.rebalance(leaf, path)
+	return value, true
+}
+
+// rebalance restores the minimum number of keys in p, which has just lost one,
+// by borrowing from or merging with a sibling, and then does the same for each
+// parent on path that loses a key in a merge.
+func (t *BPlusTree[K, V]) rebalance(p *bplusPage[K, V], path []bplusFrame[K, V]) {
+	for d := len(path) - 1; d >= 0 && len(p.keys) < t.order.minEntries; d -= 1 {
+		parent, i := path[d].page, path[d].index
+
+		switch {
+		case i > 0 && len(parent.children[i-1].keys) > t.order.minEntries:
+			t.borrowFromLeft(parent, i)
+			return
+		case i < len(parent.keys) && len(parent.children[i+1].keys) > t.order.minEntries:
+			t.borrowFromRight(parent, i)
+			return
+		case i > 0:
+			t.merge(parent, i-1)
+		default:
+			t.merge(parent, i)
+		}
+
+		p = parent
+	}
+
+	switch {
+	case len(t.root.keys) > 0:
+	case t.root.isLeaf():
+		t.root = nil
+	default:
+		t.root = t.root.children[0]
+	}
+}
+
+func (t *BPlusTree[K, V]) borrowFromLeft(parent *bplusPage[K, V], i int) {
+	left, p := parent.children[i-1], parent.children[i]
+
+	if p.isLeaf() {
+		p.keys.insertAt(0, left.keys.pop())
+		p.values.insertAt(0, left.values.pop())
+		parent.keys[i-1] = p.keys[0]
+		return
+	}
+
+	p.keys.insertAt(0, parent.keys[i-1])
+	p.children.insertAt(0, left.children.pop())
+	parent.keys[i-1] = left.keys.pop()
+}
+
+func (t *BPlusTree[K, V]) borrowFromRight(parent *bplusPage[K, V], i int) {
+	p, right := parent.children[i], parent.children[i+1]
+
+	if p.isLeaf() {
+		p.keys.add(right.keys.removeAt(0))
+		p.values.add(right.values.removeAt(0))
+		parent.keys[i] = right.keys[0]
+		return
+	}
+
+	p.keys.add(parent.keys[i])
+	p.children.add(right.children.removeAt(0))
+	parent.keys[i] = right.keys.removeAt(0)
+}
+
+// merge moves the keys of child i+1 of parent into child i, and removes child
+// i+1 and the key that separated them from parent.
+func (t *BPlusTree[K, V]) merge(parent *bplusPage[K, V], i int) {
+	left := parent.children[i]
+	separator := parent.keys.removeAt(i)
+	right := parent.children.removeAt(i + 1)
+
+	if left.isLeaf() {
+		left.keys = append(left.keys, right.keys...)
+		left.values = append(left.values, right.values...)
+		left.next = right.next
+		if right.next != nil {
+			right.next.prev = left
+		}
+		return
+	}
+
+	left.keys = append(left.keys, separator)
+	left.keys = append(left.keys, right.keys...)
+	left.children = append(left.children, right.children...)
+}
+
+// bplusFrame is a step on the path from the root to a leaf, giving a page and
+// the index of the child descended into from it.
+type bplusFrame[K any, V any] struct {
+	page  *bplusPage[K, V]
+	index int
+}
+
+// descend returns the leaf whose keys include key, and appends the path to it
+// to path.
+func (t *BPlusTree[K, V]) descend(key K, path []bplusFrame[K, V]) (*bplusPage[K, V], []bplusFrame[K, V]) {
+	p := t.root
+	for !p.isLeaf() {
+		i := p.childIndex(key, t.compare)
+		path = append(path, bplusFrame[K, V]{page: p, index: i})
+		p = p.children[i]
+	}
+	return p, path
+}
+
+// Each calls f for every entry in ascending key order.
+func (t *BPlusTree[K, V]) Each(f func(K, V)) {
+	for key, value := range t.All() {
+		f(key, value)
+	}
+}
+
+// Min returns the entry with the smallest key.
+func (t *BPlusTree[K, V]) Min() (K, V, bool) {
+	return t.leafEntry(t.firstLeaf(), 0)
+}
+
+// Max returns the entry with the largest key.
+func (t *BPlusTree[K, V]) Max() (K, V, bool) {
+	leaf := t.lastLeaf()
+	if leaf == nil {
+		return t.leafEntry(nil, 0)
+	}
+	return t.leafEntry(leaf, len(leaf.keys)-1)
+}
+
+func (t *BPlusTree[K, V]) leafEntry(leaf *bplusPage[K, V], i int) (K, V, bool) {
+	if leaf == nil {
+		var zeroKey K
+		var zeroValue V
+		return zeroKey, zeroValue, false
+	}
+	return leaf.keys[i], leaf.values[i], true
+}
+
+func (t *BPlusTree[K, V]) firstLeaf() *bplusPage[K, V] {
+	p := t.root
+	for p != nil && !p.isLeaf() {
+		p = p.children[0]
+	}
+	return p
+}
+
+func (t *BPlusTree[K, V]) lastLeaf() *bplusPage[K, V] {
+	p := t.root
+	for p != nil && !p.isLeaf() {
+		p = p.children[len(p.children)-1]
+	}
+	return p
+}
+
+// All returns an iterator over every entry in ascending key order.
+func (t *BPlusTree[K, V]) All() iter.Seq2[K, V] {
+	return t.Ascend(Unbounded[K](), Unbounded[K]())
+}
+
+// Range returns an iterator over the entries with keys in the half-open range
+// [from, to) in ascending key order.
+func (t *BPlusTree[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return t.Ascend(Inclusive(from), Exclusive(to))
+}
+
+// Ascend returns an iterator over the entries with keys from the lower bound
+// up to the upper bound in ascending key order.
+func (t *BPlusTree[K, V]) Ascend(from, to Bound[K]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		leaf, i := t.seekLower(from)
+		for ; leaf != nil; leaf, i = leaf.next, 0 {
+			for ; i < len(leaf.keys); i += 1 {
+				if !isBelow(t.compare, leaf.keys[i], to) || !yield(leaf.keys[i], leaf.values[i]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Descend returns an iterator over the entries with keys from the upper bound
+// down to the lower bound in descending key order.
+func (t *BPlusTree[K, V]) Descend(from, to Bound[K]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		leaf, i := t.seekUpper(from)
+		for leaf != nil {
+			for ; i >= 0; i -= 1 {
+				if !isAbove(t.compare, leaf.keys[i], to) || !yield(leaf.keys[i], leaf.values[i]) {
+					return
+				}
+			}
+
+			if leaf = leaf.prev; leaf != nil {
+				i = len(leaf.keys) - 1
+			}
+		}
+	}
+}
+
+// seekLower returns the leaf and index of the smallest key within the lower
+// bound, or an index past the end of the leaf when the key is in the next one.
+func (t *BPlusTree[K, V]) seekLower(b Bound[K]) (*bplusPage[K, V], int) {
+	if t.root == nil || b.kind == unbounded {
+		return t.firstLeaf(), 0
+	}
+
+	leaf, _ := t.descend(b.key, nil)
+	i, found := searchKeys(leaf.keys, b.key, t.compare)
+	if found && b.kind == exclusive {
+		i += 1
+	}
+	return leaf, i
+}
+
+// seekUpper returns the leaf and index of the largest key within the upper
+// bound, or an index of -1 when the key is in the previous leaf.
+func (t *BPlusTree[K, V]) seekUpper(b Bound[K]) (*bplusPage[K, V], int) {
+	if t.root == nil || b.kind == unbounded {
+		leaf := t.lastLeaf()
+		if leaf == nil {
+			return nil, 0
+		}
+		return leaf, len(leaf.keys) - 1
+	}
+
+	leaf, _ := t.descend(b.key, nil)
+	i, found := searchKeys(leaf.keys, b.key, t.compare)
+	if found && b.kind == inclusive {
+		return leaf, i
+	}
+	return leaf, i - 1
+}
+
+// Floor returns the entry with the greatest key less than or equal to key.
+func (t *BPlusTree[K, V]) Floor(key K) (K, V, bool) {
+	return t.leafEntry(prevInLeaves(t.seekUpper(Inclusive(key))))
+}
+
+// Lower returns the entry with the greatest key strictly less than key.
+func (t *BPlusTree[K, V]) Lower(key K) (K, V, bool) {
+	return t.leafEntry(prevInLeaves(t.seekUpper(Exclusive(key))))
+}
+
+// Ceiling returns the entry with the least key greater than or equal to key.
+func (t *BPlusTree[K, V]) Ceiling(key K) (K, V, bool) {
+	return t.leafEntry(nextInLeaves(t.seekLower(Inclusive(key))))
+}
+
+// Higher returns the entry with the least key strictly greater than key.
+func (t *BPlusTree[K, V]) Higher(key K) (K, V, bool) {
+	return t.leafEntry(nextInLeaves(t.seekLower(Exclusive(key))))
+}
+
+// nextInLeaves moves a position past the end of a leaf, as seekLower may
+// return, to the first entry of the next leaf. It returns a nil leaf if there
+// is no next leaf.
+func nextInLeaves[K any, V any](leaf *bplusPage[K, V], i int) (*bplusPage[K, V], int) {
+	if leaf != nil && i >= len(leaf.keys) {
+		return leaf.next, 0
+	}
+	return leaf, i
+}
+
+// prevInLeaves moves a position before the start of a leaf, as seekUpper may
+// return, to the last entry of the previous leaf. It returns a nil leaf if
+// there is no previous leaf.
+func prevInLeaves[K any, V any](leaf *bplusPage[K, V], i int) (*bplusPage[K, V], int) {
+	if leaf != nil && i < 0 {
+		if leaf = leaf.prev; leaf != nil {
+			return leaf, len(leaf.keys) - 1
+		}
+	}
+	return leaf, i
+}
+
+// BPlusCursor is a stateful position within a B+tree that can be moved
+// backwards and forwards through its entries in key order, like Cursor. It
+// moves between leaves along their links, so it only needs to remember the
+// leaf it is in.
+//
+// Mutating the tree invalidates every cursor over it; reposition a cursor with
+// First, Last or Seek after the tree has changed.
+type BPlusCursor[K any, V any] struct {
+	tree  *BPlusTree[K, V]
+	leaf  *bplusPage[K, V]
+	index int
+}
+
+func (t *BPlusTree[K, V]) Cursor() *BPlusCursor[K, V] {
+	return &BPlusCursor[K, V]{tree: t}
+}
+
+// Valid reports whether the cursor is positioned on an entry.
+func (c *BPlusCursor[K, V]) Valid() bool {
+	return c.leaf != nil
+}
+
+// Key returns the key of the current entry. It panics if the cursor is not valid.
+func (c *BPlusCursor[K, V]) Key() K {
+	c.mustBeValid()
+	return c.leaf.keys[c.index]
+}
+
+// Value returns the value of the current entry. It panics if the cursor is not
+// valid.
+func (c *BPlusCursor[K, V]) Value() V {
+	c.mustBeValid()
+	return c.leaf.values[c.index]
+}
+
+// First positions the cursor on the entry with the smallest key.
+func (c *BPlusCursor[K, V]) First() bool {
+	c.leaf, c.index = c.tree.firstLeaf(), 0
+	return c.Valid()
+}
+
+// Last positions the cursor on the entry with the largest key.
+func (c *BPlusCursor[K, V]) Last() bool {
+	c.leaf, c.index = prevInLeaves(c.tree.seekUpper(Unbounded[K]()))
+	return c.Valid()
+}
+
+// Seek positions the cursor on the entry with the smallest key greater than or
+// equal to key. It returns false, leaving the cursor invalid, if there is no
+// such entry.
+func (c *BPlusCursor[K, V]) Seek(key K) bool {
+	c.leaf, c.index = nextInLeaves(c.tree.seekLower(Inclusive(key)))
+	return c.Valid()
+}
+
+// Next moves the cursor to the entry with the next largest key. It returns
+// false, leaving the cursor invalid, when it moves past the last entry.
+func (c *BPlusCursor[K, V]) Next() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	c.leaf, c.index = nextInLeaves(c.leaf, c.index+1)
+	return c.Valid()
+}
+
+// Prev moves the cursor to the entry with the next smallest key. It returns
+// false, leaving the cursor invalid, when it moves past the first entry.
+func (c *BPlusCursor[K, V]) Prev() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	c.leaf, c.index = prevInLeaves(c.leaf, c.index-1)
+	return c.Valid()
+}
+
+func (c *BPlusCursor[K, V]) mustBeValid() {
+	if !c.Valid() {
+		panic("btree: cursor is not positioned on an entry")
+	}
+}
+
+// Validate checks the structure of the tree in the same way as Tree.Validate,
+// and also that the leaves are linked in order.
+func (t *BPlusTree[K, V]) Validate() error {
+	v := bplusValidator[K, V]{order: t.order, compare: t.compare, leafDepth: -1}
+
+	if t.root != nil {
+		if err := v.validateSubtree(t.root, 0, nil, nil); err != nil {
+			return err
+		}
+
+		if len(t.root.keys) == 0 {
+			return v.fail("root is empty", 0, t.root)
+		}
+		if v.last.next != nil {
+			return v.fail("last leaf links to a next leaf", v.leafDepth, v.last)
+		}
+	}
+
+	if v.numEntries != t.numEntries {
+		return v.fail(fmt.Sprintf("tree has %d entries but Len is %d", v.numEntries, t.numEntries), -1, nil)
+	}
+
+	return nil
+}
+
+type bplusValidator[K any, V any] struct {
+	order      order
+	compare    func(a, b K) int
+	leafDepth  int
+	numEntries int
+
+	// last is the last leaf visited, which the next leaf must link back to.
+	last *bplusPage[K, V]
+}
+
+// validateSubtree checks the subtree rooted at p, whose keys must all be at
+// least lo and less than hi where they are given.
+func (v *bplusValidator[K, V]) validateSubtree(p *bplusPage[K, V], depth int, lo, hi *K) error {
+	// The bounds of each child are found from the keys, so the number of
+	// children is checked first.
+	if !p.isLeaf() && (len(p.keys) != len(p.children)-1 || len(p.values) > 0) {
+		return v.fail("n children but not n-1 keys", depth, p)
+	}
+
+	for i, child := range p.children {
+		childLo, childHi := lo, hi
+		if i > 0 {
+			childLo = &p.keys[i-1]
+		}
+		if i < len(p.keys) {
+			childHi = &p.keys[i]
+		}
+
+		if err := v.validateSubtree(child, depth+1, childLo, childHi); err != nil {
+			return err
+		}
+	}
+
+	return v.validatePage(p, depth, lo, hi)
+}
+
+func (v *bplusValidator[K, V]) validatePage(p *bplusPage[K, V], depth int, lo, hi *K) error {
+	o := v.order
+
+	if len(p.keys) > o.maxEntries || cap(p.keys) > o.maxEntries+1 {
+		return v.fail("too many keys", depth, p)
+	}
+	if depth > 0 && len(p.keys) < o.minEntries {
+		return v.fail("too few keys", depth, p)
+	}
+
+	if p.isLeaf() {
+		if v.leafDepth < 0 {
+			v.leafDepth = depth
+		} else if depth != v.leafDepth {
+			return v.fail(fmt.Sprintf("leaf at depth %d, expected %d", depth, v.leafDepth), depth, p)
+		}
+
+		if len(p.values) != len(p.keys) {
+			return v.fail("n keys but not n values", depth, p)
+		}
+
+		// The leaves are linked in order in both directions.
+		if p.prev != v.last || (v.last != nil && v.last.next != p) {
+			return v.fail("leaf not linked to the previous leaf", depth, p)
+		}
+		v.last = p
+		v.numEntries += len(p.keys)
+	}
+
+	for i, key := range p.keys {
+		if i > 0 && v.compare(p.keys[i-1], key) >= 0 {
+			return v.fail("keys out of order", depth, p)
+		}
+		if lo != nil && v.compare(*lo, key) > 0 {
+			return v.fail("key less than separator", depth, p)
+		}
+		if hi != nil && v.compare(key, *hi) >= 0 {
+			return v.fail("key not less than separator", depth, p)
+		}
+	}
+
+	return nil
+}
+
+func (v *bplusValidator[K, V]) fail(invariant string, depth int, p *bplusPage[K, V]) error {
+	err := &InvariantError[K]{Invariant: invariant, Depth: depth}
+	if p != nil && len(p.keys) > 0 {
+		err.Keys = append([]K(nil), p.keys...)
+	}
+	return err
+}
@@ -0,0 +1,212 @@
+package btree_test
+
+import (
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBPlusTree(t *testing.T) {
+	t.Run("it stays valid for the given order as keys are added and removed", func(t *testing.T) {
+		propFunc := func(keys []int8, k uint8) bool {
+			// Given
+			bpt := btree.NewBPlusWithOrder[int8, int8](int(k%4) + 1)
+
+			for i, key := range keys {
+				// When
+				if i%3 == 0 {
+					bpt.Delete(key)
+				} else {
+					bpt.Insert(key, key)
+				}
+
+				// Then
+				if !assert.NoError(t, bpt.Validate(), "k=%d key=%d", k%4+1, key) {
+					return false
+				}
+			}
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("deleting every key empties the tree", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			bt := newBPlusTreeFrom(keys)
+
+			// When
+			for _, key := range shuffled(keys) {
+				bt.Delete(key)
+				if !assert.NoError(t, bt.Validate()) {
+					return false
+				}
+			}
+
+			// Then
+			_, _, found := bt.Min()
+			return assert.Zero(t, bt.Len()) && assert.False(t, found)
+		}
+
+		require.NoError(t, quick.Check(propFunc, nil))
+	})
+
+	t.Run("it iterates over ranges in the same order as Tree", func(t *testing.T) {
+		propFunc := func(keys []int8, a, b int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+			bpt := newBPlusTreeFrom(keys)
+
+			for _, from := range []btree.Bound[int8]{btree.Unbounded[int8](), btree.Inclusive(a), btree.Exclusive(a)} {
+				for _, to := range []btree.Bound[int8]{btree.Unbounded[int8](), btree.Inclusive(b), btree.Exclusive(b)} {
+					// When
+					ascending := collectKeys(bpt.Ascend(from, to))
+					descending := collectKeys(bpt.Descend(to, from))
+
+					// Then
+					if !(assert.Equal(t, collectKeys(bt.Ascend(from, to)), ascending) &&
+						assert.Equal(t, collectKeys(bt.Descend(to, from)), descending)) {
+						return false
+					}
+				}
+			}
+
+			return assert.Equal(t, collectKeys(bt.Range(a, b)), collectKeys(bpt.Range(a, b))) &&
+				assert.Equal(t, collectKeys(bt.All()), collectKeys(bpt.All()))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it finds the smallest and largest keys", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			bpt := newBPlusTreeFrom(keys)
+
+			// When
+			minKey, _, minFound := bpt.Min()
+			maxKey, _, maxFound := bpt.Max()
+
+			// Then
+			if len(keys) == 0 {
+				return assert.False(t, minFound) && assert.False(t, maxFound)
+			}
+			return assert.True(t, minFound) && assert.Equal(t, slices.Min(keys), minKey) &&
+				assert.True(t, maxFound) && assert.Equal(t, slices.Max(keys), maxKey)
+		}
+
+		require.NoError(t, quick.Check(propFunc, nil))
+	})
+
+	t.Run("it finds the nearest keys in the same way as Tree", func(t *testing.T) {
+		propFunc := func(keys []int8, key int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+			bpt := newBPlusTreeFrom(keys)
+
+			for name, lookups := range map[string][2]func(int8) (int8, int8, bool){
+				"Floor":   {bt.Floor, bpt.Floor},
+				"Lower":   {bt.Lower, bpt.Lower},
+				"Ceiling": {bt.Ceiling, bpt.Ceiling},
+				"Higher":  {bt.Higher, bpt.Higher},
+			} {
+				// When
+				gotKey, gotValue, found := lookups[1](key)
+
+				// Then
+				expectedKey, expectedValue, expectedFound := lookups[0](key)
+				if !(assert.Equal(t, expectedFound, found, "%s(%d)", name, key) &&
+					assert.Equal(t, expectedKey, gotKey, "%s(%d)", name, key) &&
+					assert.Equal(t, expectedValue, gotValue, "%s(%d)", name, key)) {
+					return false
+				}
+			}
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("its cursor moves through the entries in the same way as Tree's", func(t *testing.T) {
+		propFunc := func(keys []int8, sought int8, moves []bool) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+			bpt := newBPlusTreeFrom(keys)
+			expected, c := bt.Cursor(), bpt.Cursor()
+
+			for _, position := range []func(){
+				func() { assert.Equal(t, expected.First(), c.First(), "First") },
+				func() { assert.Equal(t, expected.Last(), c.Last(), "Last") },
+				func() { assert.Equal(t, expected.Seek(sought), c.Seek(sought), "Seek(%d)", sought) },
+			} {
+				position()
+
+				// When moving forwards for each true and backwards for each false
+				for _, forwards := range moves {
+					if !assert.Equal(t, expected.Valid(), c.Valid()) {
+						return false
+					}
+					if c.Valid() && !(assert.Equal(t, expected.Key(), c.Key()) && assert.Equal(t, expected.Value(), c.Value())) {
+						return false
+					}
+
+					if forwards {
+						assert.Equal(t, expected.Next(), c.Next(), "Next")
+					} else {
+						assert.Equal(t, expected.Prev(), c.Prev(), "Prev")
+					}
+				}
+			}
+
+			// Then
+			return !t.Failed()
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("its cursor is not valid when the tree is empty", func(t *testing.T) {
+		// Given
+		bpt := btree.NewBPlus[int, int]()
+		c := bpt.Cursor()
+
+		// Then
+		require.False(t, c.Valid())
+		require.False(t, c.First())
+		require.False(t, c.Last())
+		require.False(t, c.Seek(1))
+		require.False(t, c.Next())
+		require.False(t, c.Prev())
+		require.Panics(t, func() { c.Key() })
+	})
+
+	t.Run("it stops iterating when the caller stops", func(t *testing.T) {
+		// Given
+		bpt := newBPlusTreeFrom([]int8{1, 2, 3, 4, 5, 6, 7, 8})
+
+		// When
+		visited := make([]int8, 0)
+		for key := range bpt.All() {
+			visited = append(visited, key)
+			if key == 5 {
+				break
+			}
+		}
+
+		// Then
+		require.Equal(t, []int8{1, 2, 3, 4, 5}, visited)
+	})
+}
+
+func newBPlusTreeFrom(keys []int8) btree.BPlusTree[int8, int8] {
+	bpt := btree.NewBPlus[int8, int8]()
+	for _, key := range keys {
+		bpt.Insert(key, key)
+	}
+	return bpt
+}
@@ -1,89 +1,413 @@
 package btree
 
 import (
+	"cmp"
+	"fmt"
+
 	"golang.org/x/exp/constraints"
 )
 
-const (
-	k           int = 1
-	maxEntries  int = 2 * k           // 2
-	minEntries  int = maxEntries / 2  // 1
-	maxChildren int = maxEntries + 1  // 3
-	minChildren int = maxChildren / 2 // 1
-)
+const defaultK int = 1
+
+// maxPathLen is enough steps from the root to a leaf for a tree of order 1 with
+// well over a billion entries, so that paths rarely need to be heap allocated.
+const maxPathLen = 32
+
+// order holds the page size limits derived from the order k of a tree.
+type order struct {
+	maxEntries  int // 2k
+	minEntries  int // k
+	maxChildren int // 2k+1
+	minChildren int // ⌊(2k+1)/2⌋
+}
+
+func newOrder(k int) order {
+	if k < 1 {
+		panic(fmt.Sprintf("btree: order must be at least 1, got %d", k))
+	}
+
+	maxEntries := 2 * k
+	maxChildren := maxEntries + 1
 
-type Tree[K constraints.Ordered, V any] struct {
+	return order{
+		maxEntries:  maxEntries,
+		minEntries:  maxEntries / 2,
+		maxChildren: maxChildren,
+		minChildren: maxChildren / 2,
+	}
+}
+
+// Tree is a B-tree mapping keys to values in the order given by its
+// comparator.
+//
+// The zero value is not ready to use. Create one with New, NewFunc or
+// NewWithOrder. A zero value that is used anyway has the default order, but
+// panics when the first key is added, as it has no comparator.
+type Tree[K any, V any] struct {
 	root       *page[K, V]
 	numEntries int
+	order      order
+	compare    func(a, b K) int
+	owner      *owner
+	multi      bool
 }
 
 func New[K constraints.Ordered, V any]() Tree[K, V] {
-	return Tree[K, V]{}
+	return NewWithOrder[K, V](defaultK)
+}
+
+// NewWithOrder returns an empty tree of order k, whose pages hold between k
+// and 2k entries (the root may hold fewer) and at most 2k+1 children.
+func NewWithOrder[K constraints.Ordered, V any](k int) Tree[K, V] {
+	return NewFuncWithOrder[K, V](k, cmp.Compare[K])
+}
+
+// NewFunc returns an empty tree that orders its keys using compare, which must
+// return a negative number when a < b, a positive number when a > b and zero
+// when a and b are equal, in the same way as cmp.Compare.
+func NewFunc[K any, V any](compare func(a, b K) int) Tree[K, V] {
+	return NewFuncWithOrder[K, V](defaultK, compare)
+}
+
+// NewFuncWithOrder returns an empty tree of order k that orders its keys using
+// compare.
+func NewFuncWithOrder[K any, V any](k int, compare func(a, b K) int) Tree[K, V] {
+	return Tree[K, V]{order: newOrder(k), compare: compare, owner: new(owner)}
+}
+
+// Clone returns a copy of the tree in O(1) time. The copy shares its pages
+// with the original, and each tree copies the pages on the path to a change
+// before making it, so either can be modified without affecting the other.
+//
+// A clone can be read from other goroutines while the original carries on
+// being modified, as long as each tree is only used from one goroutine at a
+// time. Use Clone rather than assignment to copy a tree.
+func (t *Tree[K, V]) Clone() Tree[K, V] {
+	clone := *t
+	clone.owner = new(owner)
+	t.owner = new(owner)
+	return clone
+}
+
+// ready prepares a tree to take its first entries, filling in the default
+// order if it was not made by one of the constructors, and panicking if it has
+// no comparator.
+func (t *Tree[K, V]) ready() {
+	if t.compare == nil {
+		panic("btree: Tree has no comparator; create one with New, NewFunc or NewWithOrder")
+	}
+	if t.order == (order{}) {
+		t.order = newOrder(defaultK)
+	}
+	if t.owner == nil {
+		t.owner = new(owner)
+	}
 }
 
 func (t *Tree[K, V]) Len() int {
 	return t.numEntries
 }
 
+// Insert sets the value of key, adding an entry for it if there is none. In a
+// multi tree, it sets the value of the first entry with key.
 func (t *Tree[K, V]) Insert(key K, value V) {
-	var leaf *page[K, V]
-
 	if t.root == nil {
-		t.root = newPage[K, V](nil)
-		leaf = t.root
-	} else {
-		var entry *entry[K, V]
-		entry, leaf = t.root.find(key)
+		t.ready()
+		t.root = newPage[K, V](t.order, t.owner)
+	}
 
-		if entry != nil {
-			entry.value = value
-			return
+	var buf [maxPathLen]pathFrame[K, V]
+	var path []pathFrame[K, V]
+	var found bool
+
+	if t.multi {
+		var i int
+		if i, found = t.indexOf(key); found {
+			path = t.mutablePathAt(i, buf[:0])
+		} else {
+			path, _ = t.mutablePath(key, t.searchFunc(false), buf[:0])
 		}
+	} else {
+		path, found = t.mutablePath(key, t.compare, buf[:0])
 	}
 
-	leaf.add(key, value)
+	if found {
+		top := path[len(path)-1]
+		top.page.entries[top.index].value = value
+		return
+	}
+
+	t.insertAt(path, entry[K, V]{key: key, value: value})
+}
+
+// insertAt adds e to the leaf at the end of path, at the index given by its
+// frame, and then splits any pages on the path that have overflowed.
+func (t *Tree[K, V]) insertAt(path []pathFrame[K, V], e entry[K, V]) {
+	top := path[len(path)-1]
+	top.page.entries.insertAt(top.index, e)
 	t.numEntries += 1
+	for _, frame := range path {
+		frame.page.count += 1
+	}
 
-	if len(leaf.entries) > maxEntries {
-		t.split(leaf)
+	for d := len(path) - 1; d >= 0 && len(path[d].page.entries) > t.order.maxEntries; d -= 1 {
+		t.split(path, d)
 	}
 }
 
-func (t *Tree[K, V]) split(p *page[K, V]) {
-	if p.isRoot() {
-		newRoot := newPage[K, V](nil)
-		newRoot.setChildren(p)
-		t.root = newRoot
-	}
+// split moves the upper half of the entries of the overflowing page at depth
+// d of path into a new right sibling, and the median entry up into the parent.
+func (t *Tree[K, V]) split(path []pathFrame[K, V], d int) {
+	p := path[d].page
 
-	newRight := newPage[K, V](p.parent)
-	newRight.entries = p.entries.splitAt(minEntries + 1)
+	var parent *page[K, V]
+	var i int
 
-	if len(p.children) > 0 {
-		newRight.setChildren(p.children.splitAt(minChildren + 1)...)
+	if d == 0 {
+		parent = newPage[K, V](t.order, t.owner)
+		parent.addChildren(t.order, p)
+		parent.count = p.count
+		t.root = parent
+	} else {
+		parent, i = path[d-1].page, path[d-1].index
 	}
 
-	p.parent.entries.add(p.entries.pop())
-	p.parent.children.add(newRight)
-	p.parent.sort()
+	newRight := newPage[K, V](t.order, t.owner)
+	newRight.entries = append(newRight.entries, p.entries[t.order.minEntries+1:]...)
+	p.entries.truncate(t.order.minEntries + 1)
 
-	if len(p.parent.entries) > maxEntries {
-		t.split(p.parent)
+	if !p.isLeaf() {
+		newRight.addChildren(t.order, p.children[t.order.minChildren+1:]...)
+		p.children.truncate(t.order.minChildren + 1)
 	}
+
+	newRight.recount()
+	p.count -= newRight.count
+
+	parent.entries.insertAt(i, p.entries.pop())
+	p.count -= 1
+	parent.children.insertAt(i+1, newRight)
 }
 
+// Find returns the value of key. In a multi tree, it returns the value of the
+// first entry with key.
 func (t *Tree[K, V]) Find(key K) (V, bool) {
 	var zeroValue V
 	if t.root == nil {
 		return zeroValue, false
 	}
 
-	entry, _ := t.root.find(key)
-	if entry == nil {
+	if t.multi {
+		e := t.above(key, false)
+		if e == nil || t.compare(e.key, key) != 0 {
+			return zeroValue, false
+		}
+		return e.value, true
+	}
+
+	p, i, found := t.root.find(key, t.compare)
+	if !found {
+		return zeroValue, false
+	}
+
+	return p.entries[i].value, true
+}
+
+// Delete removes the entry with key and returns its value. In a multi tree, it
+// removes the first entry with key.
+func (t *Tree[K, V]) Delete(key K) (V, bool) {
+	var zeroValue V
+	if t.root == nil {
+		return zeroValue, false
+	}
+
+	var buf [maxPathLen]pathFrame[K, V]
+	var path []pathFrame[K, V]
+	var found bool
+
+	if t.multi {
+		var i int
+		if i, found = t.indexOf(key); found {
+			path = t.mutablePathAt(i, buf[:0])
+		}
+	} else {
+		path, found = t.mutablePath(key, t.compare, buf[:0])
+	}
+
+	if !found {
 		return zeroValue, false
 	}
 
-	return entry.value, true
+	return t.deleteAt(path), true
+}
+
+// deleteAt removes the entry at the end of path, and returns its value.
+func (t *Tree[K, V]) deleteAt(path []pathFrame[K, V]) V {
+	top := path[len(path)-1]
+	p := top.page
+	value := p.entries[top.index].value
+
+	if p.isLeaf() {
+		p.entries.removeAt(top.index)
+	} else {
+		// Replace the entry with its in-order predecessor, which always lives in
+		// a leaf, so that the removal itself only ever shrinks a leaf page.
+		leaf := p.mutableChild(t.order, top.index)
+		for !leaf.isLeaf() {
+			path = append(path, pathFrame[K, V]{page: leaf, index: len(leaf.children) - 1})
+			leaf = leaf.mutableChild(t.order, len(leaf.children)-1)
+		}
+		path = append(path, pathFrame[K, V]{page: leaf, index: len(leaf.entries) - 1})
+
+		p.entries[top.index] = leaf.entries.pop()
+	}
+
+	t.numEntries -= 1
+	for _, frame := range path {
+		frame.page.count -= 1
+	}
+	t.rebalance(path)
+
+	return value
+}
+
+// rebalance restores the minimum number of entries of the pages along path
+// after an entry has been removed from the page at the end of it.
+func (t *Tree[K, V]) rebalance(path []pathFrame[K, V]) {
+	for d := len(path) - 1; d > 0; d -= 1 {
+		if len(path[d].page.entries) >= t.order.minEntries {
+			return
+		}
+
+		parent, i := path[d-1].page, path[d-1].index
+
+		if i > 0 && len(parent.children[i-1].entries) > t.order.minEntries {
+			t.borrowFromLeft(parent, i)
+			return
+		}
+
+		if i < len(parent.children)-1 && len(parent.children[i+1].entries) > t.order.minEntries {
+			t.borrowFromRight(parent, i)
+			return
+		}
+
+		if i > 0 {
+			t.merge(parent, i-1)
+		} else {
+			t.merge(parent, i)
+		}
+	}
+
+	if len(t.root.entries) == 0 {
+		if t.root.isLeaf() {
+			t.root = nil
+		} else {
+			t.root = t.root.children[0]
+		}
+	}
+}
+
+// borrowFromLeft rotates the last entry of the left sibling of
+// parent.children[i] up into the parent, and the separating parent entry down
+// into parent.children[i].
+func (t *Tree[K, V]) borrowFromLeft(parent *page[K, V], i int) {
+	p, left := parent.children[i], parent.mutableChild(t.order, i-1)
+
+	p.entries.insertAt(0, parent.entries[i-1])
+	parent.entries[i-1] = left.entries.pop()
+	moved := 1
+
+	if !left.isLeaf() {
+		child := left.children.pop()
+		p.children.insertAt(0, child)
+		moved += child.count
+	}
+
+	p.count += moved
+	left.count -= moved
+}
+
+// borrowFromRight rotates the first entry of the right sibling of
+// parent.children[i] up into the parent, and the separating parent entry down
+// into parent.children[i].
+func (t *Tree[K, V]) borrowFromRight(parent *page[K, V], i int) {
+	p, right := parent.children[i], parent.mutableChild(t.order, i+1)
+
+	p.entries.add(parent.entries[i])
+	parent.entries[i] = right.entries.removeAt(0)
+	moved := 1
+
+	if !right.isLeaf() {
+		child := right.children.removeAt(0)
+		p.children.add(child)
+		moved += child.count
+	}
+
+	p.count += moved
+	right.count -= moved
+}
+
+// merge combines parent.children[i], the parent entry separating it from its
+// right sibling and that right sibling into a single page.
+func (t *Tree[K, V]) merge(parent *page[K, V], i int) {
+	left, right := parent.mutableChild(t.order, i), parent.children[i+1]
+
+	left.entries.add(parent.entries.removeAt(i))
+	left.entries = append(left.entries, right.entries...)
+
+	if !left.isLeaf() {
+		left.addChildren(t.order, right.children...)
+	}
+
+	left.count += 1 + right.count
+	parent.children.removeAt(i + 1)
+}
+
+// mutablePath descends to key, appending each step to path, in the same way
+// as page.find. Any page on the way that the tree does not own is replaced by
+// a copy first, so that every page on the returned path can be modified.
+func (t *Tree[K, V]) mutablePath(key K, compare func(a, b K) int, path []pathFrame[K, V]) ([]pathFrame[K, V], bool) {
+	if t.root.owner != t.owner {
+		t.root = t.root.clone(t.order, t.owner)
+	}
+
+	p := t.root
+	for {
+		i, found := p.search(key, compare)
+		path = append(path, pathFrame[K, V]{page: p, index: i})
+
+		if found || p.isLeaf() {
+			return path, found
+		}
+
+		p = p.mutableChild(t.order, i)
+	}
+}
+
+// mutablePathAt descends to the entry at index i in key order, in the same way
+// as Select, appending each step to path and copying pages as mutablePath does.
+func (t *Tree[K, V]) mutablePathAt(i int, path []pathFrame[K, V]) []pathFrame[K, V] {
+	if t.root.owner != t.owner {
+		t.root = t.root.clone(t.order, t.owner)
+	}
+
+	p := t.root
+	for !p.isLeaf() {
+		j := 0
+		for i > p.children[j].count {
+			i -= p.children[j].count + 1
+			j += 1
+		}
+
+		path = append(path, pathFrame[K, V]{page: p, index: j})
+		if i == p.children[j].count {
+			return path
+		}
+
+		p = p.mutableChild(t.order, j)
+	}
+
+	return append(path, pathFrame[K, V]{page: p, index: i})
 }
 
 func (t *Tree[K, V]) Each(f func(K, V)) {
@@ -0,0 +1,209 @@
+package btree_test
+
+import (
+	"fmt"
+	"iter"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/munckymagik/gokb/btree"
+)
+
+var (
+	benchmarkOrders = []int{1, 16, 32, 64, 128}
+	benchmarkSizes  = []int{1_000, 10_000, 100_000, 1_000_000}
+)
+
+// benchmarkKeys returns the keys 0..n-1 either shuffled or in ascending order.
+func benchmarkKeys(n int, order string) []int {
+	if order == "random" {
+		return rand.Perm(n)
+	}
+
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = i
+	}
+	return keys
+}
+
+func forEachBenchmarkInput(b *testing.B, f func(b *testing.B, keys []int)) {
+	for _, order := range []string{"random", "sequential"} {
+		for _, n := range benchmarkSizes {
+			keys := benchmarkKeys(n, order)
+			b.Run(fmt.Sprintf("%s/n=%d", order, n), func(b *testing.B) {
+				f(b, keys)
+			})
+		}
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	forEachBenchmarkInput(b, func(b *testing.B, keys []int) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i += 1 {
+			bt := btree.New[int, int]()
+			for _, key := range keys {
+				bt.Insert(key, key)
+			}
+		}
+	})
+}
+
+func BenchmarkFind(b *testing.B) {
+	forEachBenchmarkInput(b, func(b *testing.B, keys []int) {
+		bt := btree.New[int, int]()
+		for _, key := range keys {
+			bt.Insert(key, key)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i += 1 {
+			bt.Find(keys[i%len(keys)])
+		}
+	})
+}
+
+func BenchmarkEach(b *testing.B) {
+	forEachBenchmarkInput(b, func(b *testing.B, keys []int) {
+		bt := btree.New[int, int]()
+		for _, key := range keys {
+			bt.Insert(key, key)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i += 1 {
+			sum := 0
+			bt.Each(func(key int, _ int) {
+				sum += key
+			})
+		}
+	})
+}
+
+func BenchmarkInsertByOrder(b *testing.B) {
+	keys := rand.Perm(100_000)
+
+	for _, k := range benchmarkOrders {
+		b.Run(fmt.Sprintf("k=%d", k), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i += 1 {
+				bt := btree.NewWithOrder[int, int](k)
+				for _, key := range keys {
+					bt.Insert(key, key)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFindByOrder(b *testing.B) {
+	keys := rand.Perm(100_000)
+
+	for _, k := range benchmarkOrders {
+		b.Run(fmt.Sprintf("k=%d", k), func(b *testing.B) {
+			bt := btree.NewWithOrder[int, int](k)
+			for _, key := range keys {
+				bt.Insert(key, key)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i += 1 {
+				bt.Find(keys[i%len(keys)])
+			}
+		})
+	}
+}
+
+// BenchmarkStringKeyMemory reports the heap used per key by a tree of URL keys
+// with long prefixes in common, with and without prefix compression. Larger
+// pages have more keys to share each prefix.
+func BenchmarkStringKeyMemory(b *testing.B) {
+	const n = 100_000
+	ids := rand.Perm(n)
+	makeKey := func(id int) string {
+		return fmt.Sprintf("https://www.example.com/catalogue/products/%d/reviews/%d", id%1000, id)
+	}
+
+	for _, k := range []int{1, 32} {
+		b.Run(fmt.Sprintf("Tree/k=%d", k), func(b *testing.B) {
+			for i := 0; i < b.N; i += 1 {
+				reportHeapPerKey(b, n, func() any {
+					bt := btree.NewWithOrder[string, struct{}](k)
+					for _, id := range ids {
+						bt.Insert(makeKey(id), struct{}{})
+					}
+					return &bt
+				})
+			}
+		})
+
+		b.Run(fmt.Sprintf("StringTree/k=%d", k), func(b *testing.B) {
+			for i := 0; i < b.N; i += 1 {
+				reportHeapPerKey(b, n, func() any {
+					st := btree.NewStringTreeWithOrder[struct{}](k)
+					for _, id := range ids {
+						st.Insert(makeKey(id), struct{}{})
+					}
+					return &st
+				})
+			}
+		})
+	}
+}
+
+// reportHeapPerKey reports the growth in the live heap caused by keeping what
+// build returns, divided by the number of keys it holds.
+func reportHeapPerKey(b *testing.B, keys int, build func() any) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	kept := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(kept)
+
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(keys), "B/key")
+}
+
+func BenchmarkBPlusInsert(b *testing.B) {
+	forEachBenchmarkInput(b, func(b *testing.B, keys []int) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i += 1 {
+			bt := btree.NewBPlus[int, int]()
+			for _, key := range keys {
+				bt.Insert(key, key)
+			}
+		}
+	})
+}
+
+// BenchmarkAllByImplementation compares iterating over every entry of a Tree,
+// which moves up and down between its pages, with a BPlusTree, which walks
+// along its leaves.
+func BenchmarkAllByImplementation(b *testing.B) {
+	keys := rand.Perm(100_000)
+	bt := btree.NewWithOrder[int, int](32)
+	bpt := btree.NewBPlusWithOrder[int, int](32)
+	for _, key := range keys {
+		bt.Insert(key, key)
+		bpt.Insert(key, key)
+	}
+
+	for name, all := range map[string]func() iter.Seq2[int, int]{"Tree": bt.All, "BPlusTree": bpt.All} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i += 1 {
+				sum := 0
+				for key := range all() {
+					sum += key
+				}
+			}
+		})
+	}
+}
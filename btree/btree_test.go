@@ -1,7 +1,9 @@
 package btree_test
 
 import (
+	"cmp"
 	"math/rand"
+	"strings"
 	"testing"
 	"testing/quick"
 
@@ -12,6 +14,150 @@ import (
 	"golang.org/x/exp/slices"
 )
 
+func TestNewWithOrder(t *testing.T) {
+	t.Run("it panics when the order is less than one", func(t *testing.T) {
+		require.Panics(t, func() { btree.NewWithOrder[int, int](0) })
+	})
+
+	t.Run("it maintains the invariant for the given order as keys are added and removed", func(t *testing.T) {
+		for _, k := range []int{1, 2, 3, 4, 8, 16} {
+			propFunc := func(keys []int8) bool {
+				// Given
+				bt := btree.NewWithOrder[int8, emptyValue](k)
+
+				for _, key := range keys {
+					// When
+					bt.Insert(key, emptyValue{})
+
+					// Then
+					if !assert.NotPanics(t, bt.AssertInvariantsHold, "k=%d key=%d", k, key) {
+						return false
+					}
+				}
+
+				for _, key := range shuffled(keys) {
+					// When
+					bt.Delete(key)
+
+					// Then
+					if !assert.NotPanics(t, bt.AssertInvariantsHold, "k=%d key=%d", k, key) {
+						return false
+					}
+				}
+
+				return assert.Zero(t, bt.Len())
+			}
+
+			require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 200}), "k=%d", k)
+		}
+	})
+}
+
+func TestNewFunc(t *testing.T) {
+	t.Run("it orders composite keys using the comparator", func(t *testing.T) {
+		// Given
+		type travelTimeKey struct {
+			zoneID, restaurantID, riderID string
+		}
+		bt := btree.NewFunc[travelTimeKey, int](func(a, b travelTimeKey) int {
+			return cmp.Or(
+				cmp.Compare(a.zoneID, b.zoneID),
+				cmp.Compare(a.restaurantID, b.restaurantID),
+				cmp.Compare(a.riderID, b.riderID),
+			)
+		})
+
+		// When
+		bt.Insert(travelTimeKey{"zone2", "restaurant1", "rider1"}, 3)
+		bt.Insert(travelTimeKey{"zone1", "restaurant2", "rider1"}, 2)
+		bt.Insert(travelTimeKey{"zone1", "restaurant1", "rider2"}, 1)
+		bt.Insert(travelTimeKey{"zone1", "restaurant1", "rider1"}, 0)
+
+		// Then
+		value, found := bt.Find(travelTimeKey{"zone1", "restaurant2", "rider1"})
+		require.True(t, found)
+		require.Equal(t, 2, value)
+
+		collected := make([]int, 0)
+		bt.Each(func(_ travelTimeKey, value int) {
+			collected = append(collected, value)
+		})
+		require.Equal(t, []int{0, 1, 2, 3}, collected)
+	})
+
+	t.Run("it treats keys the comparator considers equal as the same key", func(t *testing.T) {
+		// Given
+		bt := btree.NewFunc[string, int](func(a, b string) int {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		})
+		bt.Insert("Hello", 1)
+
+		// When
+		bt.Insert("HELLO", 2)
+
+		// Then
+		value, found := bt.Find("hello")
+		require.True(t, found)
+		require.Equal(t, 2, value)
+		require.Equal(t, 1, bt.Len())
+	})
+
+	t.Run("it maintains the invariant using the comparator", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			descending := func(a, b int8) int { return cmp.Compare(b, a) }
+			bt := btree.NewFuncWithOrder[int8, emptyValue](2, descending)
+
+			for _, key := range keys {
+				// When
+				bt.Insert(key, emptyValue{})
+
+				// Then
+				if !assert.NotPanics(t, bt.AssertInvariantsHold, "key=%d", key) {
+					return false
+				}
+			}
+
+			for _, key := range shuffled(keys) {
+				// When
+				bt.Delete(key)
+
+				// Then
+				if !assert.NotPanics(t, bt.AssertInvariantsHold, "key=%d", key) {
+					return false
+				}
+			}
+
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it iterates in the order of the comparator", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			descending := func(a, b int8) int { return cmp.Compare(b, a) }
+			bt := btree.NewFunc[int8, emptyValue](descending)
+			for _, key := range keys {
+				bt.Insert(key, emptyValue{})
+			}
+
+			// When
+			collected := collectKeys(bt.All())
+
+			// Then
+			expected := cloneSortAndCompact(keys)
+			for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+				expected[i], expected[j] = expected[j], expected[i]
+			}
+			return assert.Equal(t, expected, collected)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+}
+
 func TestBTreeInsert(t *testing.T) {
 	t.Run("when the key already exists it updates the value", func(t *testing.T) {
 		// Given
@@ -26,6 +172,17 @@ func TestBTreeInsert(t *testing.T) {
 		require.Equal(t, 789, value)
 	})
 
+	t.Run("a tree that was not constructed panics saying how to make one", func(t *testing.T) {
+		// Given
+		var bt btree.Tree[int, int]
+
+		// When
+		insert := func() { bt.Insert(1, 1) }
+
+		// Then
+		require.PanicsWithValue(t, "btree: Tree has no comparator; create one with New, NewFunc or NewWithOrder", insert)
+	})
+
 	t.Run("it maintains the invariant as new keys are added", func(t *testing.T) {
 		propFunc := func(keys []int) bool {
 			// Given
@@ -112,6 +269,107 @@ func TestBTreeFind(t *testing.T) {
 	})
 }
 
+func TestBTreeDelete(t *testing.T) {
+	t.Run("when the key exists it removes the entry and returns its value", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+		bt.Insert(123, 456)
+
+		// When
+		value, found := bt.Delete(123)
+
+		// Then
+		require.True(t, found)
+		require.Equal(t, 456, value)
+		require.Zero(t, bt.Len())
+		_, found = bt.Find(123)
+		require.False(t, found)
+	})
+
+	t.Run("when the key does not exist it returns the zero value of the type", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+		bt.Insert(123, 456)
+
+		// When
+		value, found := bt.Delete(789)
+
+		// Then
+		require.False(t, found)
+		require.Equal(t, 0, value)
+		require.Equal(t, 1, bt.Len())
+	})
+
+	t.Run("it does not crash when the tree is empty", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+
+		// When
+		_, found := bt.Delete(123)
+
+		// Then
+		require.False(t, found)
+	})
+
+	t.Run("it maintains the invariant as keys are removed", func(t *testing.T) {
+		propFunc := func(keys []int) bool {
+			// Given
+			sortedSetKeys := cloneSortAndCompact(keys)
+			bt := newBTreeFrom(keys)
+
+			for i, key := range shuffled(sortedSetKeys) {
+				// When
+				value, found := bt.Delete(key)
+
+				// Then
+				if !(assert.True(t, found, "key=%d", key) && assert.Equal(t, key, value)) {
+					return false
+				}
+				if !assert.NotPanics(t, bt.AssertInvariantsHold, "key=%d", key) {
+					return false
+				}
+				if !assert.Equal(t, len(sortedSetKeys)-i-1, bt.Len()) {
+					return false
+				}
+			}
+
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it only removes the deleted keys", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			sortedSetKeys := cloneSortAndCompact(keys)
+			removed := shuffled(sortedSetKeys)[:len(sortedSetKeys)/2]
+			bt := newBTreeFrom(keys)
+
+			// When
+			for _, key := range removed {
+				bt.Delete(key)
+			}
+
+			// Then
+			expected := make([]int8, 0)
+			for _, key := range sortedSetKeys {
+				if !slices.Contains(removed, key) {
+					expected = append(expected, key)
+				}
+			}
+			collected := make([]int8, 0)
+			bt.Each(func(key int8, _ int8) {
+				collected = append(collected, key)
+			})
+
+			return assert.Equal(t, expected, collected)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+}
+
 func TestBTreeEach(t *testing.T) {
 	t.Run("it does not crash or execute when the tree is empty", func(t *testing.T) {
 		// Given
@@ -187,7 +445,7 @@ func newBTreeFrom[K constraints.Ordered](keys []K) btree.Tree[K, K] {
 func shuffled[T any](elems []T) []T {
 	elems = slices.Clone(elems)
 	rand.Shuffle(len(elems), func(i, j int) {
-		elems[i] = elems[j]
+		elems[i], elems[j] = elems[j], elems[i]
 	})
 
 	return elems
@@ -0,0 +1,186 @@
+// Package btreetest provides tests that any implementation of
+// btree.OrderedMap should pass.
+package btreetest
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOrderedMap checks that the maps returned by newMap behave like a Go map
+// that keeps its keys in the order given by compare. Each call to newMap must
+// return a new, empty map. Each map is checked against a btree.SliceMap given
+// the same changes.
+//
+// The keys and values put in the maps are made by genKey and genValue from
+// the random source they are given. The tests are more thorough when genKey
+// often makes the same key twice, so that keys are replaced and deleted.
+func TestOrderedMap[K any, V any](
+	t *testing.T,
+	newMap func() btree.OrderedMap[K, V],
+	compare func(a, b K) int,
+	genKey func(*rand.Rand) K,
+	genValue func(*rand.Rand) V,
+) {
+	t.Run("it behaves like a map", func(t *testing.T) {
+		propFunc := func(seed int64, n uint8) bool {
+			// Given
+			rng := rand.New(rand.NewSource(seed))
+			m := newMap()
+			o := btree.NewSliceMapFunc[K, V](compare)
+
+			for i := range int(n) {
+				key := genKey(rng)
+
+				// When
+				if _, ok := o.Find(key); ok && i%3 == 0 {
+					value, found := m.Delete(key)
+					expected, _ := o.Delete(key)
+					if !(assert.True(t, found, "key=%v", key) && assert.Equal(t, expected, value, "key=%v", key)) {
+						return false
+					}
+				} else {
+					value := genValue(rng)
+					m.Insert(key, value)
+					o.Insert(key, value)
+				}
+
+				// Then
+				if !assert.Equal(t, o.Len(), m.Len()) {
+					return false
+				}
+			}
+
+			for range int(n) {
+				key := genKey(rng)
+				value, found := m.Find(key)
+				expected, ok := o.Find(key)
+				if !(assert.Equal(t, ok, found, "key=%v", key) && assert.Equal(t, expected, value, "key=%v", key)) {
+					return false
+				}
+			}
+			for key, expected := range o.All() {
+				value, found := m.Find(key)
+				if !(assert.True(t, found, "key=%v", key) && assert.Equal(t, expected, value, "key=%v", key)) {
+					return false
+				}
+			}
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("inserting an existing key replaces its value", func(t *testing.T) {
+		// Given
+		rng := rand.New(rand.NewSource(1))
+		keys := distinctKeys(t, rng, genKey, compare, 2)
+		m := newMap()
+		m.Insert(keys[0], genValue(rng))
+		m.Insert(keys[1], genValue(rng))
+
+		// When
+		replacement := genValue(rng)
+		m.Insert(keys[0], replacement)
+
+		// Then
+		value, found := m.Find(keys[0])
+		require.True(t, found)
+		require.Equal(t, replacement, value)
+		require.Equal(t, 2, m.Len())
+	})
+
+	t.Run("deleting a missing key changes nothing", func(t *testing.T) {
+		// Given
+		rng := rand.New(rand.NewSource(1))
+		keys := distinctKeys(t, rng, genKey, compare, 2)
+		m := newMap()
+		m.Insert(keys[0], genValue(rng))
+
+		// When
+		value, found := m.Delete(keys[1])
+
+		// Then
+		require.False(t, found)
+		require.Zero(t, value)
+		require.Equal(t, 1, m.Len())
+	})
+
+	t.Run("it yields the entries in a range in ascending order", func(t *testing.T) {
+		propFunc := func(seed int64, n uint8) bool {
+			// Given
+			rng := rand.New(rand.NewSource(seed))
+			m := newMap()
+			o := btree.NewSliceMapFunc[K, V](compare)
+			for range int(n) {
+				key, value := genKey(rng), genValue(rng)
+				m.Insert(key, value)
+				o.Insert(key, value)
+			}
+			from, to := genKey(rng), genKey(rng)
+
+			// When
+			rangeKeys := make([]K, 0)
+			for key, value := range m.Range(from, to) {
+				expected, _ := o.Find(key)
+				if !assert.Equal(t, expected, value, "key=%v", key) {
+					return false
+				}
+				rangeKeys = append(rangeKeys, key)
+			}
+
+			// Then
+			expected := make([]K, 0)
+			for key := range o.Range(from, to) {
+				expected = append(expected, key)
+			}
+			return assert.Equal(t, expected, rangeKeys)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it stops iterating when the caller stops", func(t *testing.T) {
+		// Given
+		rng := rand.New(rand.NewSource(1))
+		keys := distinctKeys(t, rng, genKey, compare, 10)
+		slices.SortFunc(keys, compare)
+		m := newMap()
+		for _, key := range keys {
+			m.Insert(key, genValue(rng))
+		}
+
+		// When
+		visited := make([]K, 0)
+		for key := range m.Range(keys[2], keys[8]) {
+			visited = append(visited, key)
+			if len(visited) == 3 {
+				break
+			}
+		}
+
+		// Then
+		require.Equal(t, keys[2:5], visited)
+	})
+}
+
+// distinctKeys returns n keys made by genKey that are all different, failing
+// the test if genKey does not make that many.
+func distinctKeys[K any](t *testing.T, rng *rand.Rand, genKey func(*rand.Rand) K, compare func(a, b K) int, n int) []K {
+	keys := make([]K, 0, n)
+	for tries := 0; len(keys) < n; tries += 1 {
+		require.Less(t, tries, 1000*n, "genKey made fewer than %d different keys", n)
+
+		key := genKey(rng)
+		if !slices.ContainsFunc(keys, func(k K) bool { return compare(k, key) == 0 }) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,237 @@
+package btree
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// filePage is the decoded form of a page stored in a block of a file.
+type filePage[K any, V any] struct {
+	id       blockID
+	entries  items[entry[K, V]]
+	children items[blockID]
+	dirty    bool
+}
+
+func (p *filePage[K, V]) isLeaf() bool {
+	return len(p.children) == 0
+}
+
+// bufferPool caches decoded pages, evicting the least recently used once there
+// are more than capacity of them and writing them back if they have changed.
+//
+// Pages are only evicted by trim, so a page returned by get remains the one and
+// only copy of that page until the next call to trim.
+type bufferPool[K any, V any] struct {
+	pager    *pager
+	order    order
+	keys     Codec[K]
+	values   Codec[V]
+	capacity int
+	pages    map[blockID]*list.Element
+	history  *list.List
+}
+
+func newBufferPool[K any, V any](pg *pager, o order, keys Codec[K], values Codec[V], capacity int) *bufferPool[K, V] {
+	return &bufferPool[K, V]{
+		pager:    pg,
+		order:    o,
+		keys:     keys,
+		values:   values,
+		capacity: capacity,
+		pages:    make(map[blockID]*list.Element),
+		history:  list.New(),
+	}
+}
+
+func (bp *bufferPool[K, V]) get(id blockID) (*filePage[K, V], error) {
+	if elem, ok := bp.pages[id]; ok {
+		bp.history.MoveToFront(elem)
+		return elem.Value.(*filePage[K, V]), nil
+	}
+
+	block, err := bp.pager.read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := bp.decode(id, block)
+	if err != nil {
+		return nil, err
+	}
+
+	bp.pages[id] = bp.history.PushFront(p)
+	return p, nil
+}
+
+// allocate returns a new empty page, which is written out when it is evicted
+// or flushed.
+func (bp *bufferPool[K, V]) allocate() (*filePage[K, V], error) {
+	id, err := bp.pager.allocate()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filePage[K, V]{
+		id:      id,
+		entries: make(items[entry[K, V]], 0, bp.order.maxEntries+1),
+		dirty:   true,
+	}
+	bp.pages[id] = bp.history.PushFront(p)
+	return p, nil
+}
+
+// free drops a page from the pool and releases its block.
+func (bp *bufferPool[K, V]) free(p *filePage[K, V]) {
+	if elem, ok := bp.pages[p.id]; ok {
+		bp.history.Remove(elem)
+		delete(bp.pages, p.id)
+	}
+	bp.pager.free(p.id)
+}
+
+// trim evicts the least recently used pages until the pool is within capacity.
+func (bp *bufferPool[K, V]) trim() error {
+	for bp.history.Len() > bp.capacity {
+		evictee := bp.history.Back()
+		p := evictee.Value.(*filePage[K, V])
+
+		if err := bp.writeBack(p); err != nil {
+			return err
+		}
+
+		bp.history.Remove(evictee)
+		delete(bp.pages, p.id)
+	}
+	return nil
+}
+
+// flush writes back every changed page and syncs the file.
+func (bp *bufferPool[K, V]) flush() error {
+	for elem := bp.history.Front(); elem != nil; elem = elem.Next() {
+		if err := bp.writeBack(elem.Value.(*filePage[K, V])); err != nil {
+			return err
+		}
+	}
+	return bp.pager.sync()
+}
+
+func (bp *bufferPool[K, V]) writeBack(p *filePage[K, V]) error {
+	if !p.dirty {
+		return nil
+	}
+
+	block, err := bp.encode(p)
+	if err != nil {
+		return err
+	}
+
+	if err := bp.pager.write(p.id, block); err != nil {
+		return err
+	}
+
+	p.dirty = false
+	return nil
+}
+
+// encode lays a page out in a block as its kind, its number of entries, the
+// block ids of its children and then its entries, each of which is a
+// length-prefixed key followed by a length-prefixed value.
+func (bp *bufferPool[K, V]) encode(p *filePage[K, V]) ([]byte, error) {
+	block := make([]byte, 0, bp.pager.blockSize())
+
+	if p.isLeaf() {
+		block = append(block, leafBlock)
+	} else {
+		block = append(block, internalBlock)
+	}
+	block = binary.BigEndian.AppendUint16(block, uint16(len(p.entries)))
+
+	for _, child := range p.children {
+		block = binary.BigEndian.AppendUint32(block, uint32(child))
+	}
+
+	var err error
+	for _, e := range p.entries {
+		if block, err = appendLengthPrefixed(block, bp.keys, e.key); err != nil {
+			return nil, err
+		}
+		if block, err = appendLengthPrefixed(block, bp.values, e.value); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(block) > bp.pager.blockSize() {
+		return nil, fmt.Errorf("%w: page %d needs %d bytes", ErrEntryTooLarge, p.id, len(block))
+	}
+
+	return block[:cap(block)], nil
+}
+
+func (bp *bufferPool[K, V]) decode(id blockID, block []byte) (*filePage[K, V], error) {
+	kind := block[0]
+	if kind != leafBlock && kind != internalBlock {
+		return nil, fmt.Errorf("%w: block %d is not a page", ErrCorrupt, id)
+	}
+
+	n := int(binary.BigEndian.Uint16(block[1:]))
+	rest := block[3:]
+
+	p := &filePage[K, V]{id: id, entries: make(items[entry[K, V]], 0, bp.order.maxEntries+1)}
+
+	if kind == internalBlock {
+		if len(rest) < 4*(n+1) {
+			return nil, fmt.Errorf("%w: block %d is truncated", ErrCorrupt, id)
+		}
+
+		p.children = make(items[blockID], 0, bp.order.maxChildren+1)
+		for i := 0; i <= n; i += 1 {
+			p.children.add(blockID(binary.BigEndian.Uint32(rest)))
+			rest = rest[4:]
+		}
+	}
+
+	for i := 0; i < n; i += 1 {
+		var e entry[K, V]
+		var err error
+
+		if e.key, rest, err = decodeLengthPrefixed(rest, bp.keys); err != nil {
+			return nil, fmt.Errorf("%w: block %d: %w", ErrCorrupt, id, err)
+		}
+		if e.value, rest, err = decodeLengthPrefixed(rest, bp.values); err != nil {
+			return nil, fmt.Errorf("%w: block %d: %w", ErrCorrupt, id, err)
+		}
+
+		p.entries.add(e)
+	}
+
+	return p, nil
+}
+
+func appendLengthPrefixed[T any](buf []byte, codec Codec[T], value T) ([]byte, error) {
+	encoded, err := codec.Append(nil, value)
+	if err != nil {
+		return nil, err
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(encoded)))
+	return append(buf, encoded...), nil
+}
+
+func decodeLengthPrefixed[T any](data []byte, codec Codec[T]) (T, []byte, error) {
+	var zero T
+
+	length, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < length {
+		return zero, nil, errors.New("bad length prefix")
+	}
+
+	value, err := codec.Decode(data[n : n+int(length)])
+	if err != nil {
+		return zero, nil, err
+	}
+
+	return value, data[n+int(length):], nil
+}
@@ -0,0 +1,138 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+var (
+	ErrNotEmpty     = errors.New("btree: tree is not empty")
+	ErrUnsorted     = errors.New("btree: keys are not in ascending order")
+	ErrDuplicateKey = errors.New("btree: duplicate key")
+)
+
+// Pair is a key and its value.
+type Pair[K any, V any] struct {
+	Key   K
+	Value V
+}
+
+// BulkLoad builds a tree from seq, which must yield keys in strictly ascending
+// order.
+func BulkLoad[K constraints.Ordered, V any](seq iter.Seq2[K, V]) (Tree[K, V], error) {
+	t := New[K, V]()
+	err := t.Load(seq)
+	return t, err
+}
+
+// FromSorted builds a tree from pairs, which must be sorted by key in strictly
+// ascending order.
+func FromSorted[K constraints.Ordered, V any](pairs []Pair[K, V]) (Tree[K, V], error) {
+	return BulkLoad(func(yield func(K, V) bool) {
+		for _, pair := range pairs {
+			if !yield(pair.Key, pair.Value) {
+				return
+			}
+		}
+	})
+}
+
+// Load fills an empty tree from seq, which must yield keys in strictly
+// ascending order according to the tree's comparator, or in ascending order for
+// a multi tree. Rather than inserting one key at a time, the pages are packed
+// bottom-up in linear time.
+//
+// It returns an error, leaving the tree empty, if the tree already has entries
+// or if seq yields a key out of order or more than once.
+func (t *Tree[K, V]) Load(seq iter.Seq2[K, V]) error {
+	if t.root != nil {
+		return ErrNotEmpty
+	}
+	t.ready()
+
+	entries := make([]entry[K, V], 0)
+	for key, value := range seq {
+		if n := len(entries); n > 0 {
+			prev := entries[n-1].key
+			switch c := t.compare(prev, key); {
+			case c == 0 && !t.multi:
+				return fmt.Errorf("%w: %v", ErrDuplicateKey, key)
+			case c > 0:
+				return fmt.Errorf("%w: %v follows %v", ErrUnsorted, key, prev)
+			}
+		}
+
+		entries = append(entries, entry[K, V]{key: key, value: value})
+	}
+
+	t.build(entries)
+	return nil
+}
+
+// build fills an empty tree with entries, which are already in order.
+func (t *Tree[K, V]) build(entries []entry[K, V]) {
+	if len(entries) == 0 {
+		return
+	}
+
+	height := 1
+	for t.order.maxSubtreeEntries(height) < len(entries) {
+		height += 1
+	}
+
+	t.root = t.buildSubtree(true, entries, height)
+	t.numEntries = len(entries)
+}
+
+// buildSubtree builds a subtree of the given height holding entries. It gives
+// the page as few children as will hold all the entries, and shares the entries
+// out evenly between them, so that every page is as full as the height allows.
+func (t *Tree[K, V]) buildSubtree(isRoot bool, entries []entry[K, V], height int) *page[K, V] {
+	p := newPage[K, V](t.order, t.owner)
+	p.count = len(entries)
+
+	if height == 1 {
+		p.entries = append(p.entries, entries...)
+		return p
+	}
+
+	maxPerChild := t.order.maxSubtreeEntries(height - 1)
+	numChildren := (len(entries) + maxPerChild + 1) / (maxPerChild + 1)
+	if isRoot {
+		numChildren = max(numChildren, 2)
+	} else {
+		numChildren = max(numChildren, t.order.minEntries+1)
+	}
+
+	childEntries := len(entries) - (numChildren - 1)
+	start := 0
+	for i := 0; i < numChildren; i += 1 {
+		size := childEntries / numChildren
+		if i < childEntries%numChildren {
+			size += 1
+		}
+
+		p.addChildren(t.order, t.buildSubtree(false, entries[start:start+size], height-1))
+		start += size
+
+		if i < numChildren-1 {
+			p.entries.add(entries[start])
+			start += 1
+		}
+	}
+
+	return p
+}
+
+// maxSubtreeEntries returns the number of entries held by a full subtree of the
+// given height.
+func (o order) maxSubtreeEntries(height int) int {
+	n := 0
+	for ; height > 0; height -= 1 {
+		n = n*o.maxChildren + o.maxEntries
+	}
+	return n
+}
@@ -0,0 +1,126 @@
+package btree_test
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromSorted(t *testing.T) {
+	t.Run("it builds a tree containing every pair", func(t *testing.T) {
+		// Given
+		pairs := []btree.Pair[int, string]{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}}
+
+		// When
+		bt, err := btree.FromSorted(pairs)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 4, bt.Len())
+		value, found := bt.Find(3)
+		require.True(t, found)
+		require.Equal(t, "c", value)
+	})
+
+	t.Run("it builds an empty tree from no pairs", func(t *testing.T) {
+		// When
+		bt, err := btree.FromSorted[int, int](nil)
+
+		// Then
+		require.NoError(t, err)
+		require.Zero(t, bt.Len())
+		require.NotPanics(t, bt.AssertInvariantsHold)
+	})
+
+	t.Run("it returns an error when the keys are not sorted", func(t *testing.T) {
+		// Given
+		pairs := []btree.Pair[int, int]{{1, 1}, {3, 3}, {2, 2}}
+
+		// When
+		bt, err := btree.FromSorted(pairs)
+
+		// Then
+		require.ErrorIs(t, err, btree.ErrUnsorted)
+		require.Zero(t, bt.Len())
+	})
+
+	t.Run("it returns an error when a key is duplicated", func(t *testing.T) {
+		// Given
+		pairs := []btree.Pair[int, int]{{1, 1}, {2, 2}, {2, 3}}
+
+		// When
+		bt, err := btree.FromSorted(pairs)
+
+		// Then
+		require.ErrorIs(t, err, btree.ErrDuplicateKey)
+		require.Zero(t, bt.Len())
+	})
+}
+
+func TestBTreeLoad(t *testing.T) {
+	t.Run("it returns an error when the tree is not empty", func(t *testing.T) {
+		// Given
+		bt := newBTreeFrom([]int{1})
+		source := newBTreeFrom([]int{2})
+
+		// When
+		err := bt.Load(source.All())
+
+		// Then
+		require.ErrorIs(t, err, btree.ErrNotEmpty)
+		require.Equal(t, 1, bt.Len())
+	})
+
+	t.Run("it builds a valid tree of every size and order", func(t *testing.T) {
+		for _, k := range []int{1, 2, 3, 4, 16} {
+			for n := 0; n < 500; n += 1 {
+				// Given
+				keys := make([]int, n)
+				for i := range keys {
+					keys[i] = i
+				}
+				source := newBTreeFrom(keys)
+				bt := btree.NewWithOrder[int, int](k)
+
+				// When
+				err := bt.Load(source.All())
+
+				// Then
+				require.NoError(t, err)
+				require.NotPanics(t, bt.AssertInvariantsHold, "k=%d n=%d", k, n)
+				require.Equal(t, n, bt.Len())
+				require.Equal(t, keys, collectKeys(bt.All()))
+			}
+		}
+	})
+
+	t.Run("the tree can still be modified after loading", func(t *testing.T) {
+		propFunc := func(loaded []int8, modified []int8) bool {
+			// Given
+			source := newBTreeFrom(loaded)
+			bt := btree.New[int8, int8]()
+			if !assert.NoError(t, bt.Load(source.All())) {
+				return false
+			}
+
+			for _, key := range modified {
+				// When
+				if _, found := bt.Delete(key); !found {
+					bt.Insert(key, key)
+				}
+
+				// Then
+				if !assert.NotPanics(t, bt.AssertInvariantsHold, "key=%d", key) {
+					return false
+				}
+			}
+
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+}
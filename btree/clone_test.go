@@ -0,0 +1,143 @@
+package btree_test
+
+import (
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBTreeClone(t *testing.T) {
+	t.Run("the clone has the same entries as the original", func(t *testing.T) {
+		// Given
+		bt := newBTreeFrom([]int{3, 1, 4, 5, 9, 2, 6})
+
+		// When
+		clone := bt.Clone()
+
+		// Then
+		require.Equal(t, bt.Len(), clone.Len())
+		require.Equal(t, collectKeys(bt.All()), collectKeys(clone.All()))
+	})
+
+	t.Run("changes to either tree do not affect the other", func(t *testing.T) {
+		propFunc := func(initial []int8, originalChanges []int8, cloneChanges []int8) bool {
+			// Given
+			bt := newBTreeFrom(initial)
+			clone := bt.Clone()
+			originalOracle := newOracleFrom(initial)
+			cloneOracle := newOracleFrom(initial)
+
+			// When
+			applyChanges(&bt, originalOracle, originalChanges)
+			applyChanges(&clone, cloneOracle, cloneChanges)
+
+			// Then
+			return assert.NotPanics(t, bt.AssertInvariantsHold) &&
+				assert.NotPanics(t, clone.AssertInvariantsHold) &&
+				assert.Equal(t, originalOracle, collectEntries(&bt)) &&
+				assert.Equal(t, cloneOracle, collectEntries(&clone))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("clones of clones are independent", func(t *testing.T) {
+		propFunc := func(initial []int8, changes [][]int8) bool {
+			// Given
+			trees := []btree.Tree[int8, int8]{newBTreeFrom(initial)}
+			oracles := []map[int8]int8{newOracleFrom(initial)}
+
+			// When
+			for i, change := range changes {
+				from := i % len(trees)
+				trees = append(trees, trees[from].Clone())
+				oracles = append(oracles, cloneOracle(oracles[from]))
+				applyChanges(&trees[from], oracles[from], change)
+			}
+
+			// Then
+			for i := range trees {
+				if !(assert.NotPanics(t, trees[i].AssertInvariantsHold) &&
+					assert.Equal(t, oracles[i], collectEntries(&trees[i]))) {
+					return false
+				}
+			}
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 200}))
+	})
+
+	t.Run("a snapshot can be read while the original is modified", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+		for i := 0; i < 1000; i += 1 {
+			bt.Insert(i, i)
+		}
+
+		var wg sync.WaitGroup
+		for round := 1; round <= 4; round += 1 {
+			snapshot := bt.Clone()
+			wg.Add(1)
+
+			// When
+			go func() {
+				defer wg.Done()
+				for key, value := range snapshot.All() {
+					assert.Equal(t, key*round, value)
+				}
+				assert.Equal(t, 1000, snapshot.Len())
+			}()
+
+			for i := 0; i < 1000; i += 1 {
+				bt.Delete(i)
+				bt.Insert(i, i*(round+1))
+			}
+		}
+
+		// Then
+		wg.Wait()
+		require.NotPanics(t, bt.AssertInvariantsHold)
+	})
+}
+
+// applyChanges deletes each key in changes from the tree if it is present, or
+// inserts it otherwise, and does the same to the oracle.
+func applyChanges(bt *btree.Tree[int8, int8], oracle map[int8]int8, changes []int8) {
+	for i, key := range changes {
+		if _, found := bt.Delete(key); found {
+			delete(oracle, key)
+		} else {
+			bt.Insert(key, int8(i))
+			oracle[key] = int8(i)
+		}
+	}
+}
+
+func newOracleFrom(keys []int8) map[int8]int8 {
+	oracle := make(map[int8]int8)
+	for _, key := range keys {
+		oracle[key] = key
+	}
+	return oracle
+}
+
+func cloneOracle(oracle map[int8]int8) map[int8]int8 {
+	clone := make(map[int8]int8)
+	for key, value := range oracle {
+		clone[key] = value
+	}
+	return clone
+}
+
+func collectEntries(bt *btree.Tree[int8, int8]) map[int8]int8 {
+	entries := make(map[int8]int8)
+	for key, value := range bt.All() {
+		entries[key] = value
+	}
+	return entries
+}
@@ -0,0 +1,86 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec converts keys or values of type T to and from bytes, so that they can
+// be written to pages on disk.
+type Codec[T any] interface {
+	// Append appends the encoding of value to buf and returns the extended
+	// buffer.
+	Append(buf []byte, value T) ([]byte, error)
+
+	// Decode decodes a value from exactly the bytes produced by Append.
+	Decode(data []byte) (T, error)
+}
+
+// StringCodec encodes strings as their raw bytes.
+type StringCodec struct{}
+
+func (StringCodec) Append(buf []byte, value string) ([]byte, error) {
+	return append(buf, value...), nil
+}
+
+func (StringCodec) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// BinaryCodec encodes fixed-size values, such as integers, floats and structs
+// of them, using encoding/binary. The byte order defaults to big-endian.
+type BinaryCodec[T any] struct {
+	ByteOrder binary.ByteOrder
+}
+
+func (c BinaryCodec[T]) Append(buf []byte, value T) ([]byte, error) {
+	return binary.Append(buf, c.byteOrder(), value)
+}
+
+func (c BinaryCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	_, err := binary.Decode(data, c.byteOrder(), &value)
+	return value, err
+}
+
+func (c BinaryCodec[T]) byteOrder() binary.ByteOrder {
+	if c.ByteOrder == nil {
+		return binary.BigEndian
+	}
+	return c.ByteOrder
+}
+
+// GobCodec encodes values of any type that encoding/gob supports. Each value is
+// encoded on its own, so carries a description of its type.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Append(buf []byte, value T) ([]byte, error) {
+	b := bytes.NewBuffer(buf)
+	err := gob.NewEncoder(b).Encode(value)
+	return b.Bytes(), err
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// JSONCodec encodes values of any type that encoding/json supports.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Append(buf []byte, value T) ([]byte, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, encoded...), nil
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
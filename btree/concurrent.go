@@ -0,0 +1,448 @@
+package btree
+
+import (
+	"cmp"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/exp/constraints"
+)
+
+// ConcurrentTree is a B-tree that can be used from many goroutines at once.
+// Each page has its own latch, and operations take latches by crabbing down
+// from the root: the latch on a child is taken before the latch on its parent
+// is released, and a writer lets go of the latches above a page as soon as it
+// knows a change cannot spread up past that page.
+//
+// Readers share latches with each other, so only contend with writers that are
+// changing the same pages.
+type ConcurrentTree[K any, V any] struct {
+	// rootLatch guards root, and is held by a writer for as long as its change
+	// might replace the root.
+	rootLatch  sync.RWMutex
+	root       *latchedPage[K, V]
+	numEntries atomic.Int64
+	order      order
+	compare    func(a, b K) int
+}
+
+type latchedPage[K any, V any] struct {
+	latch    sync.RWMutex
+	entries  items[entry[K, V]]
+	children items[*latchedPage[K, V]]
+}
+
+func newLatchedPage[K any, V any](o order) *latchedPage[K, V] {
+	return &latchedPage[K, V]{entries: make(items[entry[K, V]], 0, o.maxEntries+1)}
+}
+
+func (p *latchedPage[K, V]) isLeaf() bool {
+	return len(p.children) == 0
+}
+
+func (p *latchedPage[K, V]) addChildren(o order, incoming ...*latchedPage[K, V]) {
+	if p.children == nil {
+		p.children = make(items[*latchedPage[K, V]], 0, o.maxChildren+1)
+	}
+
+	p.children = append(p.children, incoming...)
+}
+
+func NewConcurrent[K constraints.Ordered, V any]() *ConcurrentTree[K, V] {
+	return NewConcurrentFuncWithOrder[K, V](defaultK, cmp.Compare[K])
+}
+
+// NewConcurrentFunc returns an empty concurrent tree that orders its keys using
+// compare.
+func NewConcurrentFunc[K any, V any](compare func(a, b K) int) *ConcurrentTree[K, V] {
+	return NewConcurrentFuncWithOrder[K, V](defaultK, compare)
+}
+
+// NewConcurrentFuncWithOrder returns an empty concurrent tree of order k that
+// orders its keys using compare.
+func NewConcurrentFuncWithOrder[K any, V any](k int, compare func(a, b K) int) *ConcurrentTree[K, V] {
+	return &ConcurrentTree[K, V]{order: newOrder(k), compare: compare}
+}
+
+func (t *ConcurrentTree[K, V]) Len() int {
+	return int(t.numEntries.Load())
+}
+
+func (t *ConcurrentTree[K, V]) Find(key K) (V, bool) {
+	var zeroValue V
+
+	t.rootLatch.RLock()
+	p := t.root
+	if p == nil {
+		t.rootLatch.RUnlock()
+		return zeroValue, false
+	}
+	p.latch.RLock()
+	t.rootLatch.RUnlock()
+
+	for {
+		i, found := searchEntries(p.entries, key, t.compare)
+		if found {
+			value := p.entries[i].value
+			p.latch.RUnlock()
+			return value, true
+		}
+
+		if p.isLeaf() {
+			p.latch.RUnlock()
+			return zeroValue, false
+		}
+
+		child := p.children[i]
+		child.latch.RLock()
+		p.latch.RUnlock()
+		p = child
+	}
+}
+
+func (t *ConcurrentTree[K, V]) Insert(key K, value V) {
+	lp := t.latchRoot()
+	defer lp.releaseAll()
+
+	if t.root == nil {
+		t.root = newLatchedPage[K, V](t.order)
+	}
+
+	// A page with room for another entry cannot split, so nothing above it
+	// can change.
+	isSafe := func(p *latchedPage[K, V]) bool {
+		return len(p.entries) < t.order.maxEntries
+	}
+
+	for p := t.root; ; {
+		lp.latch(p, isSafe)
+
+		i, found := searchEntries(p.entries, key, t.compare)
+		lp.top().index = i
+
+		if found {
+			p.entries[i].value = value
+			return
+		}
+
+		if p.isLeaf() {
+			break
+		}
+
+		p = p.children[i]
+	}
+
+	top := lp.top()
+	top.page.entries.insertAt(top.index, entry[K, V]{key: key, value: value})
+	t.numEntries.Add(1)
+
+	for d := len(lp.frames) - 1; d >= 0 && len(lp.frames[d].page.entries) > t.order.maxEntries; d -= 1 {
+		t.split(lp.frames, d)
+	}
+}
+
+// split works in the same way as Tree.split. Only the root can overflow at
+// depth 0 of the latched path, as the parent of any other page that might
+// overflow is still latched.
+func (t *ConcurrentTree[K, V]) split(frames []latchedFrame[K, V], d int) {
+	p := frames[d].page
+
+	var parent *latchedPage[K, V]
+	var i int
+
+	if d == 0 {
+		parent = newLatchedPage[K, V](t.order)
+		parent.addChildren(t.order, p)
+		t.root = parent
+	} else {
+		parent, i = frames[d-1].page, frames[d-1].index
+	}
+
+	newRight := newLatchedPage[K, V](t.order)
+	newRight.entries = append(newRight.entries, p.entries[t.order.minEntries+1:]...)
+	p.entries.truncate(t.order.minEntries + 1)
+
+	if !p.isLeaf() {
+		newRight.addChildren(t.order, p.children[t.order.minChildren+1:]...)
+		p.children.truncate(t.order.minChildren + 1)
+	}
+
+	parent.entries.insertAt(i, p.entries.pop())
+	parent.children.insertAt(i+1, newRight)
+}
+
+func (t *ConcurrentTree[K, V]) Delete(key K) (V, bool) {
+	var zeroValue V
+
+	lp := t.latchRoot()
+	defer lp.releaseAll()
+
+	if t.root == nil {
+		return zeroValue, false
+	}
+
+	// A page with more than the fewest entries allowed cannot underflow, so
+	// nothing above it can change. The root can go down to a single entry
+	// before it risks being replaced.
+	isSafe := func(p *latchedPage[K, V]) bool {
+		return len(p.entries) > t.order.minEntries
+	}
+	isSafeRoot := func(p *latchedPage[K, V]) bool {
+		return len(p.entries) > 1
+	}
+
+	var p *latchedPage[K, V]
+	var i int
+	for p = t.root; ; {
+		if len(lp.frames) == 0 {
+			lp.latch(p, isSafeRoot)
+		} else {
+			lp.latch(p, isSafe)
+		}
+
+		var found bool
+		i, found = searchEntries(p.entries, key, t.compare)
+		lp.top().index = i
+
+		if found {
+			break
+		}
+
+		if p.isLeaf() {
+			return zeroValue, false
+		}
+
+		p = p.children[i]
+	}
+
+	value := p.entries[i].value
+
+	if p.isLeaf() {
+		p.entries.removeAt(i)
+	} else {
+		// Replace the entry with its in-order predecessor, keeping the page
+		// latched while descending to it.
+		lp.pin()
+
+		leaf := p.children[i]
+		for {
+			lp.latch(leaf, isSafe)
+			if leaf.isLeaf() {
+				lp.top().index = len(leaf.entries) - 1
+				break
+			}
+
+			lp.top().index = len(leaf.children) - 1
+			leaf = leaf.children[len(leaf.children)-1]
+		}
+
+		p.entries[i] = leaf.entries.pop()
+	}
+
+	t.numEntries.Add(-1)
+	t.rebalance(lp)
+
+	return value, true
+}
+
+// rebalance works in the same way as Tree.rebalance, latching each sibling
+// while it is borrowed from or merged. Only the root can underflow at depth 0
+// of the latched path.
+func (t *ConcurrentTree[K, V]) rebalance(lp *latchPath[K, V]) {
+	frames := lp.frames
+
+	for d := len(frames) - 1; d > 0; d -= 1 {
+		if len(frames[d].page.entries) >= t.order.minEntries {
+			return
+		}
+
+		parent, i := frames[d-1].page, frames[d-1].index
+
+		if i > 0 && t.tryBorrowFromLeft(parent, i) {
+			return
+		}
+
+		if i < len(parent.children)-1 && t.tryBorrowFromRight(parent, i) {
+			return
+		}
+
+		if i > 0 {
+			t.merge(parent, i-1, parent.children[i-1])
+		} else {
+			t.merge(parent, i, parent.children[i+1])
+		}
+	}
+
+	if lp.holdsRoot && len(t.root.entries) == 0 {
+		if t.root.isLeaf() {
+			t.root = nil
+		} else {
+			t.root = t.root.children[0]
+		}
+	}
+}
+
+// tryBorrowFromLeft rotates an entry from the left sibling of parent.children[i]
+// through the parent, as Tree.borrowFromLeft does, if the sibling can spare one.
+func (t *ConcurrentTree[K, V]) tryBorrowFromLeft(parent *latchedPage[K, V], i int) bool {
+	p, left := parent.children[i], parent.children[i-1]
+
+	left.latch.Lock()
+	defer left.latch.Unlock()
+
+	if len(left.entries) <= t.order.minEntries {
+		return false
+	}
+
+	p.entries.insertAt(0, parent.entries[i-1])
+	parent.entries[i-1] = left.entries.pop()
+
+	if !left.isLeaf() {
+		p.children.insertAt(0, left.children.pop())
+	}
+
+	return true
+}
+
+// tryBorrowFromRight is the mirror image of tryBorrowFromLeft.
+func (t *ConcurrentTree[K, V]) tryBorrowFromRight(parent *latchedPage[K, V], i int) bool {
+	p, right := parent.children[i], parent.children[i+1]
+
+	right.latch.Lock()
+	defer right.latch.Unlock()
+
+	if len(right.entries) <= t.order.minEntries {
+		return false
+	}
+
+	p.entries.add(parent.entries[i])
+	parent.entries[i] = right.entries.removeAt(0)
+
+	if !right.isLeaf() {
+		p.children.add(right.children.removeAt(0))
+	}
+
+	return true
+}
+
+// merge works in the same way as Tree.merge. One of the two pages is already
+// latched on the path, and the other, its sibling, is latched here.
+func (t *ConcurrentTree[K, V]) merge(parent *latchedPage[K, V], i int, sibling *latchedPage[K, V]) {
+	left, right := parent.children[i], parent.children[i+1]
+
+	sibling.latch.Lock()
+	defer sibling.latch.Unlock()
+
+	left.entries.add(parent.entries.removeAt(i))
+	left.entries = append(left.entries, right.entries...)
+
+	if !left.isLeaf() {
+		left.addChildren(t.order, right.children...)
+	}
+
+	parent.children.removeAt(i + 1)
+}
+
+// Each calls f for every entry in ascending key order. It holds read latches
+// on the path to the current entry, so f must not modify the tree.
+func (t *ConcurrentTree[K, V]) Each(f func(K, V)) {
+	t.rootLatch.RLock()
+	p := t.root
+	if p == nil {
+		t.rootLatch.RUnlock()
+		return
+	}
+	p.latch.RLock()
+	t.rootLatch.RUnlock()
+
+	p.traverseSubtree(f)
+}
+
+// traverseSubtree visits the subtree of a page whose read latch is held, and
+// releases it.
+func (p *latchedPage[K, V]) traverseSubtree(f func(K, V)) {
+	defer p.latch.RUnlock()
+
+	for i, e := range p.entries {
+		if !p.isLeaf() {
+			p.children[i].latch.RLock()
+			p.children[i].traverseSubtree(f)
+		}
+		f(e.key, e.value)
+	}
+
+	if !p.isLeaf() {
+		last := p.children[len(p.entries)]
+		last.latch.RLock()
+		last.traverseSubtree(f)
+	}
+}
+
+type latchedFrame[K any, V any] struct {
+	page  *latchedPage[K, V]
+	index int
+}
+
+// latchPath holds the exclusive latches of a writer, on the pages from the
+// highest that its change might still reach down to the current page.
+type latchPath[K any, V any] struct {
+	tree      *ConcurrentTree[K, V]
+	holdsRoot bool
+	frames    []latchedFrame[K, V]
+
+	// pinned is the index of a frame that must stay latched however safe the
+	// pages below it are, or -1 if there is none.
+	pinned int
+}
+
+// latchRoot starts a latch path by taking the root latch, which the caller
+// must release with releaseAll.
+func (t *ConcurrentTree[K, V]) latchRoot() *latchPath[K, V] {
+	t.rootLatch.Lock()
+	return &latchPath[K, V]{tree: t, holdsRoot: true, pinned: -1}
+}
+
+// latch takes the latch on p, which is the root or a child of the current
+// page, and makes it the current page. If isSafe then reports that a change to
+// p cannot spread up past it, the latches above it are released.
+func (lp *latchPath[K, V]) latch(p *latchedPage[K, V], isSafe func(p *latchedPage[K, V]) bool) {
+	p.latch.Lock()
+	lp.frames = append(lp.frames, latchedFrame[K, V]{page: p})
+
+	if isSafe(p) {
+		lp.releaseAbove(len(lp.frames) - 1)
+	}
+}
+
+// pin keeps the current page latched until releaseAll.
+func (lp *latchPath[K, V]) pin() {
+	lp.pinned = len(lp.frames) - 1
+}
+
+// releaseAbove releases the root latch and the latches on the first n frames,
+// or on those above the pinned frame if that comes first.
+func (lp *latchPath[K, V]) releaseAbove(n int) {
+	if lp.holdsRoot {
+		lp.tree.rootLatch.Unlock()
+		lp.holdsRoot = false
+	}
+
+	if lp.pinned >= 0 {
+		n = min(n, lp.pinned)
+		lp.pinned -= n
+	}
+
+	for _, frame := range lp.frames[:n] {
+		frame.page.latch.Unlock()
+	}
+	lp.frames = lp.frames[n:]
+}
+
+func (lp *latchPath[K, V]) releaseAll() {
+	lp.pinned = -1
+	lp.releaseAbove(len(lp.frames))
+}
+
+func (lp *latchPath[K, V]) top() *latchedFrame[K, V] {
+	return &lp.frames[len(lp.frames)-1]
+}
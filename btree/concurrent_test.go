@@ -0,0 +1,158 @@
+package btree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentTree(t *testing.T) {
+	t.Run("it behaves like a Tree when used from one goroutine", func(t *testing.T) {
+		propFunc := func(inserts []int8, deletes []int8) bool {
+			// Given
+			ct := NewConcurrent[int8, int8]()
+			bt := New[int8, int8]()
+
+			// When
+			for _, key := range inserts {
+				ct.Insert(key, key)
+				bt.Insert(key, key)
+			}
+			for _, key := range deletes {
+				ctValue, ctFound := ct.Delete(key)
+				btValue, btFound := bt.Delete(key)
+				if !(assert.Equal(t, btFound, ctFound) && assert.Equal(t, btValue, ctValue)) {
+					return false
+				}
+			}
+
+			// Then
+			return assert.NoError(t, ct.validate()) &&
+				assert.Equal(t, bt.Len(), ct.Len()) &&
+				assert.Equal(t, collectTreeEntries(&bt), collectConcurrentEntries(ct))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("many goroutines can insert, find and delete at once", func(t *testing.T) {
+		// Given
+		const writers, readers, keysPerWriter, rounds = 8, 4, 200, 3
+		ct := NewConcurrentFuncWithOrder[int, int](2, func(a, b int) int { return a - b })
+
+		// Keys shared by every goroutine, which stay in the tree throughout.
+		for key := -100; key < 0; key += 1 {
+			ct.Insert(key, key)
+		}
+
+		// When
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+
+		for w := 0; w < writers; w += 1 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				// Each writer owns the keys congruent to w, so can check them
+				// while others change the pages around them.
+				keys := make([]int, 0, keysPerWriter)
+				for i := 0; i < keysPerWriter; i += 1 {
+					keys = append(keys, i*writers+w)
+				}
+
+				for round := 1; round <= rounds; round += 1 {
+					rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+					for _, key := range keys {
+						ct.Insert(key, key*round)
+					}
+					for _, key := range keys {
+						value, found := ct.Find(key)
+						assert.True(t, found, "key=%d", key)
+						assert.Equal(t, key*round, value, "key=%d", key)
+					}
+					for _, key := range keys[:len(keys)/2] {
+						value, found := ct.Delete(key)
+						assert.True(t, found, "key=%d", key)
+						assert.Equal(t, key*round, value, "key=%d", key)
+					}
+				}
+			}()
+		}
+
+		var readersWg sync.WaitGroup
+		for r := 0; r < readers; r += 1 {
+			readersWg.Add(1)
+			go func() {
+				defer readersWg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					key := -1 - rand.Intn(100)
+					value, found := ct.Find(key)
+					assert.True(t, found, "key=%d", key)
+					assert.Equal(t, key, value, "key=%d", key)
+
+					prev, first := 0, true
+					ct.Each(func(key, _ int) {
+						assert.True(t, first || prev < key, "keys out of order: %d then %d", prev, key)
+						prev, first = key, false
+					})
+				}
+			}()
+		}
+
+		wg.Wait()
+		close(done)
+		readersWg.Wait()
+
+		// Then
+		require.NoError(t, ct.validate())
+		require.Equal(t, 100+writers*keysPerWriter/2, ct.Len())
+	})
+}
+
+// validate checks the invariants of the tree by copying it into a Tree. It must
+// not be called while the tree is being modified.
+func (t *ConcurrentTree[K, V]) validate() error {
+	var copyPage func(p *latchedPage[K, V]) *page[K, V]
+	copyPage = func(p *latchedPage[K, V]) *page[K, V] {
+		c := newPage[K, V](t.order, nil)
+		c.entries = append(c.entries, p.entries...)
+		for _, child := range p.children {
+			c.addChildren(t.order, copyPage(child))
+		}
+		c.recount()
+		return c
+	}
+
+	bt := Tree[K, V]{order: t.order, compare: t.compare, numEntries: t.Len()}
+	if t.root != nil {
+		bt.root = copyPage(t.root)
+	}
+	return bt.Validate()
+}
+
+func collectTreeEntries(bt *Tree[int8, int8]) []entry[int8, int8] {
+	collected := make([]entry[int8, int8], 0)
+	bt.Each(func(key, value int8) {
+		collected = append(collected, entry[int8, int8]{key, value})
+	})
+	return collected
+}
+
+func collectConcurrentEntries(ct *ConcurrentTree[int8, int8]) []entry[int8, int8] {
+	collected := make([]entry[int8, int8], 0)
+	ct.Each(func(key, value int8) {
+		collected = append(collected, entry[int8, int8]{key, value})
+	})
+	return collected
+}
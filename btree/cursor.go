@@ -0,0 +1,197 @@
+package btree
+
+// Cursor is a stateful position within a tree that can be moved backwards and
+// forwards through its entries in key order.
+//
+// A cursor is positioned on an entry only while Valid returns true. Mutating
+// the tree invalidates every cursor over it; reposition a cursor with First,
+// Last or Seek after the tree has changed.
+type Cursor[K any, V any] struct {
+	tree *Tree[K, V]
+
+	// stack holds the path from the root to the current page. The index of the
+	// top frame is the entry the cursor is positioned on, the index of every
+	// other frame is the child that was descended into.
+	stack []pathFrame[K, V]
+}
+
+func (t *Tree[K, V]) Cursor() *Cursor[K, V] {
+	return &Cursor[K, V]{tree: t}
+}
+
+// Valid reports whether the cursor is positioned on an entry.
+func (c *Cursor[K, V]) Valid() bool {
+	return len(c.stack) > 0
+}
+
+// Key returns the key of the current entry. It panics if the cursor is not valid.
+func (c *Cursor[K, V]) Key() K {
+	return c.current().key
+}
+
+// Value returns the value of the current entry. It panics if the cursor is not
+// valid.
+func (c *Cursor[K, V]) Value() V {
+	return c.current().value
+}
+
+// First positions the cursor on the entry with the smallest key.
+func (c *Cursor[K, V]) First() bool {
+	c.reset()
+	if c.tree.root != nil {
+		c.descendLeftmost(c.tree.root)
+	}
+	return c.Valid()
+}
+
+// Last positions the cursor on the entry with the largest key.
+func (c *Cursor[K, V]) Last() bool {
+	c.reset()
+	if c.tree.root != nil {
+		c.descendRightmost(c.tree.root)
+	}
+	return c.Valid()
+}
+
+// Seek positions the cursor on the entry with the smallest key greater than or
+// equal to key, or the first of them in a multi tree. It returns false, leaving
+// the cursor invalid, if there is no such entry.
+func (c *Cursor[K, V]) Seek(key K) bool {
+	c.reset()
+	compare := c.tree.searchFunc(false)
+
+	p := c.tree.root
+	for p != nil {
+		i, found := p.search(key, compare)
+		if found {
+			c.push(p, i)
+			return true
+		}
+
+		if p.isLeaf() {
+			if i < len(p.entries) {
+				c.push(p, i)
+				return true
+			}
+
+			// Every key in the leaf is less than the one sought, so the answer is
+			// the successor of the last of them.
+			c.push(p, len(p.entries)-1)
+			return c.Next()
+		}
+
+		c.push(p, i)
+		p = p.children[i]
+	}
+
+	return false
+}
+
+// Next moves the cursor to the entry with the next largest key. It returns
+// false, leaving the cursor invalid, when it moves past the last entry.
+func (c *Cursor[K, V]) Next() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	top := c.top()
+	if !top.page.isLeaf() {
+		top.index += 1
+		c.descendLeftmost(top.page.children[top.index])
+		return true
+	}
+
+	top.index += 1
+	if top.index < len(top.page.entries) {
+		return true
+	}
+
+	for {
+		c.pop()
+		if !c.Valid() {
+			return false
+		}
+
+		if parent := c.top(); parent.index < len(parent.page.entries) {
+			return true
+		}
+	}
+}
+
+// Prev moves the cursor to the entry with the next smallest key. It returns
+// false, leaving the cursor invalid, when it moves past the first entry.
+func (c *Cursor[K, V]) Prev() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	top := c.top()
+	if !top.page.isLeaf() {
+		c.descendRightmost(top.page.children[top.index])
+		return true
+	}
+
+	top.index -= 1
+	if top.index >= 0 {
+		return true
+	}
+
+	for {
+		c.pop()
+		if !c.Valid() {
+			return false
+		}
+
+		if parent := c.top(); parent.index > 0 {
+			parent.index -= 1
+			return true
+		}
+	}
+}
+
+func (c *Cursor[K, V]) descendLeftmost(p *page[K, V]) {
+	for !p.isLeaf() {
+		c.push(p, 0)
+		p = p.children[0]
+	}
+
+	if len(p.entries) > 0 {
+		c.push(p, 0)
+	}
+}
+
+func (c *Cursor[K, V]) descendRightmost(p *page[K, V]) {
+	for !p.isLeaf() {
+		c.push(p, len(p.children)-1)
+		p = *p.children.last()
+	}
+
+	if len(p.entries) > 0 {
+		c.push(p, len(p.entries)-1)
+	}
+}
+
+func (c *Cursor[K, V]) current() *entry[K, V] {
+	if !c.Valid() {
+		panic("btree: cursor is not positioned on an entry")
+	}
+
+	top := c.top()
+	return &top.page.entries[top.index]
+}
+
+func (c *Cursor[K, V]) top() *pathFrame[K, V] {
+	return &c.stack[len(c.stack)-1]
+}
+
+func (c *Cursor[K, V]) push(p *page[K, V], index int) {
+	c.stack = append(c.stack, pathFrame[K, V]{page: p, index: index})
+}
+
+func (c *Cursor[K, V]) pop() {
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+func (c *Cursor[K, V]) reset() {
+	c.stack = c.stack[:0]
+}
@@ -0,0 +1,129 @@
+package btree_test
+
+import (
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("it is not valid when the tree is empty", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+		c := bt.Cursor()
+
+		// Then
+		require.False(t, c.Valid())
+		require.False(t, c.First())
+		require.False(t, c.Last())
+		require.False(t, c.Seek(1))
+		require.False(t, c.Next())
+		require.False(t, c.Prev())
+		require.Panics(t, func() { c.Key() })
+	})
+
+	t.Run("Next visits every entry in ascending order", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+			c := bt.Cursor()
+
+			// When
+			collected := make([]int8, 0)
+			for ok := c.First(); ok; ok = c.Next() {
+				collected = append(collected, c.Key())
+			}
+
+			// Then
+			return assert.Equal(t, cloneSortAndCompact(keys), collected) && assert.False(t, c.Valid())
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("Prev visits every entry in descending order", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+			c := bt.Cursor()
+
+			// When
+			collected := make([]int8, 0)
+			for ok := c.Last(); ok; ok = c.Prev() {
+				collected = append(collected, c.Key())
+			}
+
+			// Then
+			expected := cloneSortAndCompact(keys)
+			slices.Reverse(expected)
+			return assert.Equal(t, expected, collected) && assert.False(t, c.Valid())
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("Seek finds the least key greater than or equal to the one sought", func(t *testing.T) {
+		propFunc := func(keys []int8, sought int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+			sortedSetKeys := cloneSortAndCompact(keys)
+			c := bt.Cursor()
+
+			// When
+			found := c.Seek(sought)
+
+			// Then
+			i, _ := slices.BinarySearch(sortedSetKeys, sought)
+			if i == len(sortedSetKeys) {
+				return assert.False(t, found) && assert.False(t, c.Valid())
+			}
+			return assert.True(t, found) &&
+				assert.Equal(t, sortedSetKeys[i], c.Key()) &&
+				assert.Equal(t, sortedSetKeys[i], c.Value())
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it can change direction from any position", func(t *testing.T) {
+		propFunc := func(keys []int8, sought int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+			sortedSetKeys := cloneSortAndCompact(keys)
+			i, _ := slices.BinarySearch(sortedSetKeys, sought)
+			c := bt.Cursor()
+
+			// When
+			if !c.Seek(sought) {
+				return true
+			}
+
+			// Then
+			if c.Next() {
+				if !(assert.Equal(t, sortedSetKeys[i+1], c.Key()) && assert.True(t, c.Prev())) {
+					return false
+				}
+			} else {
+				c.Seek(sought)
+			}
+			if !assert.Equal(t, sortedSetKeys[i], c.Key()) {
+				return false
+			}
+
+			if c.Prev() {
+				if !(assert.Equal(t, sortedSetKeys[i-1], c.Key()) && assert.True(t, c.Next())) {
+					return false
+				}
+			} else {
+				c.Seek(sought)
+			}
+			return assert.Equal(t, sortedSetKeys[i], c.Key())
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+}
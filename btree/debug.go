@@ -0,0 +1,168 @@
+package btree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Stats describes the shape of a tree.
+type Stats struct {
+	// Height is the number of levels of pages, which is 0 for an empty tree.
+	Height int
+
+	// Pages is the total number of pages.
+	Pages int
+
+	// Entries is the total number of entries.
+	Entries int
+
+	// FillFactor is the fraction of the space for entries in all the pages that
+	// is in use.
+	FillFactor float64
+
+	// Levels describes each level of the tree, starting at the root.
+	Levels []LevelStats
+}
+
+// LevelStats describes the pages at one level of a tree.
+type LevelStats struct {
+	Pages      int
+	Entries    int
+	FillFactor float64
+}
+
+// Stats returns the height of the tree and how full its pages are at each
+// level. It visits every page, so takes time proportional to the size of the
+// tree.
+func (t *Tree[K, V]) Stats() Stats {
+	var stats Stats
+
+	level := make([]*page[K, V], 0)
+	if t.root != nil {
+		level = append(level, t.root)
+	}
+
+	for len(level) > 0 {
+		next := make([]*page[K, V], 0)
+		ls := LevelStats{Pages: len(level)}
+
+		for _, p := range level {
+			ls.Entries += len(p.entries)
+			next = append(next, p.children...)
+		}
+		ls.FillFactor = t.order.fillFactor(ls.Pages, ls.Entries)
+
+		stats.Levels = append(stats.Levels, ls)
+		stats.Pages += ls.Pages
+		stats.Entries += ls.Entries
+		level = next
+	}
+
+	stats.Height = len(stats.Levels)
+	stats.FillFactor = t.order.fillFactor(stats.Pages, stats.Entries)
+
+	return stats
+}
+
+func (o order) fillFactor(pages int, entries int) float64 {
+	if pages == 0 {
+		return 0
+	}
+	return float64(entries) / float64(pages*o.maxEntries)
+}
+
+// Dump writes the pages of the tree to w, one per line in depth-first order,
+// indented by depth and showing the keys, number of children and number of
+// entries in the subtree of each.
+func (t *Tree[K, V]) Dump(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	if t.root == nil {
+		ew.printf("empty tree\n")
+		return ew.err
+	}
+
+	var dumpSubtree func(p *page[K, V], depth int)
+	dumpSubtree = func(p *page[K, V], depth int) {
+		ew.printf("%sdepth=%d keys=%v children=%d count=%d\n",
+			strings.Repeat("  ", depth), depth, p.keys(), len(p.children), p.count)
+
+		for _, child := range p.children {
+			dumpSubtree(child, depth+1)
+		}
+	}
+	dumpSubtree(t.root, 0)
+
+	return ew.err
+}
+
+// WriteDOT writes the pages of the tree to w as a Graphviz digraph, with each
+// page drawn as a record of its keys and an edge from between each pair of
+// keys to the child holding the keys between them.
+func (t *Tree[K, V]) WriteDOT(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("digraph btree {\n")
+	ew.printf("  node [shape=record];\n")
+
+	id := 0
+	var writeSubtree func(p *page[K, V]) int
+	writeSubtree = func(p *page[K, V]) int {
+		pageID := id
+		id += 1
+
+		fields := make([]string, 0, 2*len(p.entries)+1)
+		for i, e := range p.entries {
+			fields = append(fields, fmt.Sprintf("<c%d>", i), dotEscape(fmt.Sprint(e.key)))
+		}
+		fields = append(fields, fmt.Sprintf("<c%d>", len(p.entries)))
+		ew.printf("  p%d [label=\"%s\"];\n", pageID, strings.Join(fields, "|"))
+
+		for i, child := range p.children {
+			childID := writeSubtree(child)
+			ew.printf("  p%d:c%d -> p%d;\n", pageID, i, childID)
+		}
+
+		return pageID
+	}
+
+	if t.root != nil {
+		writeSubtree(t.root)
+	}
+
+	ew.printf("}\n")
+	return ew.err
+}
+
+// dotEscape escapes the characters that have a special meaning in the label of
+// a Graphviz record.
+func dotEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`\"{}|<> `, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (p *page[K, V]) keys() []K {
+	keys := make([]K, len(p.entries))
+	for i, e := range p.entries {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// errWriter formats output to w until the first error, which it keeps.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
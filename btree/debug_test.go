@@ -0,0 +1,161 @@
+package btree_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBTreeDump(t *testing.T) {
+	t.Run("it reports an empty tree", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+		var out strings.Builder
+
+		// When
+		err := bt.Dump(&out)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, "empty tree\n", out.String())
+	})
+
+	t.Run("it writes each page indented by depth", func(t *testing.T) {
+		// Given
+		bt := newSortedBTree(9)
+		var out strings.Builder
+
+		// When
+		err := bt.Dump(&out)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, strings.Join([]string{
+			"depth=0 keys=[5] children=2 count=9",
+			"  depth=1 keys=[3] children=2 count=4",
+			"    depth=2 keys=[1 2] children=0 count=2",
+			"    depth=2 keys=[4] children=0 count=1",
+			"  depth=1 keys=[8] children=2 count=4",
+			"    depth=2 keys=[6 7] children=0 count=2",
+			"    depth=2 keys=[9] children=0 count=1",
+			"",
+		}, "\n"), out.String())
+	})
+
+	t.Run("it returns the first error from the writer", func(t *testing.T) {
+		// Given
+		bt := newSortedBTree(9)
+		w := &failingWriter{failAfter: 2}
+
+		// When
+		err := bt.Dump(w)
+
+		// Then
+		require.ErrorIs(t, err, errWriteFailed)
+		require.Equal(t, 3, w.writes)
+	})
+}
+
+func TestBTreeWriteDOT(t *testing.T) {
+	t.Run("it draws each page as a record with an edge to each child", func(t *testing.T) {
+		// Given
+		bt := newSortedBTree(3)
+		var out strings.Builder
+
+		// When
+		err := bt.WriteDOT(&out)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, strings.Join([]string{
+			"digraph btree {",
+			"  node [shape=record];",
+			`  p0 [label="<c0>|2|<c1>"];`,
+			`  p1 [label="<c0>|1|<c1>"];`,
+			"  p0:c0 -> p1;",
+			`  p2 [label="<c0>|3|<c1>"];`,
+			"  p0:c1 -> p2;",
+			"}",
+			"",
+		}, "\n"), out.String())
+	})
+
+	t.Run("it escapes keys", func(t *testing.T) {
+		// Given
+		bt := btree.New[string, int]()
+		bt.Insert(`a|b "c"`, 1)
+		var out strings.Builder
+
+		// When
+		err := bt.WriteDOT(&out)
+
+		// Then
+		require.NoError(t, err)
+		require.Contains(t, out.String(), `label="<c0>|a\|b\ \"c\"|<c1>"`)
+	})
+}
+
+func TestBTreeStats(t *testing.T) {
+	t.Run("an empty tree has no levels", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+
+		// When
+		stats := bt.Stats()
+
+		// Then
+		require.Equal(t, btree.Stats{}, stats)
+	})
+
+	t.Run("it describes each level", func(t *testing.T) {
+		// Given
+		bt := newSortedBTree(9)
+
+		// When
+		stats := bt.Stats()
+
+		// Then
+		require.Equal(t, 3, stats.Height)
+		require.Equal(t, 7, stats.Pages)
+		require.Equal(t, 9, stats.Entries)
+		require.InDelta(t, 9.0/14.0, stats.FillFactor, 1e-9)
+		require.Equal(t, []btree.LevelStats{
+			{Pages: 1, Entries: 1, FillFactor: 0.5},
+			{Pages: 2, Entries: 2, FillFactor: 0.5},
+			{Pages: 4, Entries: 6, FillFactor: 0.75},
+		}, stats.Levels)
+	})
+}
+
+// newSortedBTree bulk loads a tree of order 1 with the keys 1 to n.
+func newSortedBTree(n int) btree.Tree[int, int] {
+	pairs := make([]btree.Pair[int, int], 0, n)
+	for key := 1; key <= n; key += 1 {
+		pairs = append(pairs, btree.Pair[int, int]{Key: key, Value: key})
+	}
+
+	bt, err := btree.FromSorted(pairs)
+	if err != nil {
+		panic(err)
+	}
+	return bt
+}
+
+var errWriteFailed = errors.New("write failed")
+
+// failingWriter fails every write after the first failAfter.
+type failingWriter struct {
+	failAfter int
+	writes    int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes += 1
+	if w.writes > w.failAfter {
+		return 0, errWriteFailed
+	}
+	return len(p), nil
+}
@@ -0,0 +1,467 @@
+package btree
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/exp/constraints"
+)
+
+const (
+	checkpointFile = "checkpoint"
+	logFile        = "wal"
+
+	defaultBatchSize       = 64
+	defaultCheckpointEvery = 4096
+
+	// recordHeaderSize is the space taken by the length and checksum of the
+	// payload at the start of every log record.
+	recordHeaderSize = 4 + 4
+)
+
+// The first byte of every log record payload says which operation it records.
+const (
+	insertRecord byte = iota + 1
+	deleteRecord
+)
+
+// ErrLogFailed is returned by every change to a DurableTree after a failed
+// append could not be cut back out of the log.
+var ErrLogFailed = errors.New("btree: log could not be restored after a failed write")
+
+// SyncPolicy says how often a DurableTree syncs its log to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncEveryOp syncs the log before each change returns, so that no change
+	// that has returned is lost in a crash.
+	SyncEveryOp SyncPolicy = iota
+
+	// SyncBatched syncs the log after every BatchSize changes, so that a crash
+	// loses at most the changes since the last batch.
+	SyncBatched
+
+	// SyncNever leaves it to the operating system to write the log out, other
+	// than at checkpoints and when the tree is closed.
+	SyncNever
+)
+
+// DurableOptions configures a DurableTree.
+type DurableOptions struct {
+	// Sync says how often the log is synced. Defaults to SyncEveryOp.
+	Sync SyncPolicy
+
+	// BatchSize is the number of changes between syncs with SyncBatched.
+	// Defaults to 64.
+	BatchSize int
+
+	// CheckpointEvery is the number of changes after which the tree is
+	// checkpointed automatically. Defaults to 4096, and a negative value turns
+	// automatic checkpoints off. An automatic checkpoint that fails does not
+	// fail the change that started it, which is already in the log, and is
+	// tried again after the next change. See CheckpointErr.
+	CheckpointEvery int
+}
+
+func (o *DurableOptions) withDefaults() DurableOptions {
+	var opts DurableOptions
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.BatchSize == 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.CheckpointEvery == 0 {
+		opts.CheckpointEvery = defaultCheckpointEvery
+	}
+
+	return opts
+}
+
+// DurableTree is an in-memory Tree that survives restarts. Every change is
+// appended to a write-ahead log before it is made, and opening the tree
+// replays the log on top of the last checkpoint, a snapshot of the whole tree
+// written in the same format as Serializer. A checkpoint empties the log.
+//
+// A record torn by a crash part way through appending it is discarded when the
+// log is replayed, so the recovered tree holds a prefix of the changes made.
+// A change that returns an error is cut back out of the log, and if that fails
+// too the tree takes no more changes until a checkpoint empties the log.
+type DurableTree[K any, V any] struct {
+	tree   Tree[K, V]
+	dir    string
+	keys   Codec[K]
+	values Codec[V]
+	opts   DurableOptions
+	log    writeAheadLog
+
+	// logSize is the size of the log up to the end of its last good record,
+	// and failed is the error that stopped the tree taking changes, if any.
+	logSize int64
+	failed  error
+
+	// checkpointErr is the error from the last automatic checkpoint.
+	checkpointErr error
+
+	// unsynced counts the changes logged since the last sync, and logged those
+	// since the last checkpoint.
+	unsynced int
+	logged   int
+}
+
+// OpenDurable opens the tree kept in the directory dir, creating the directory
+// if it does not exist. Keys and values are written to disk using the given
+// codecs.
+func OpenDurable[K constraints.Ordered, V any](dir string, keys Codec[K], values Codec[V], opts *DurableOptions) (*DurableTree[K, V], error) {
+	return OpenDurableFunc(dir, cmp.Compare[K], keys, values, opts)
+}
+
+// OpenDurableFunc is like OpenDurable but orders the keys using compare.
+func OpenDurableFunc[K any, V any](dir string, compare func(a, b K) int, keys Codec[K], values Codec[V], opts *DurableOptions) (*DurableTree[K, V], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	t := &DurableTree[K, V]{
+		tree:   NewFunc[K, V](compare),
+		dir:    dir,
+		keys:   keys,
+		values: values,
+		opts:   opts.withDefaults(),
+	}
+
+	if err := t.readCheckpoint(); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	t.log = log
+
+	if err := t.replay(); err != nil {
+		log.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *DurableTree[K, V]) Len() int {
+	return t.tree.Len()
+}
+
+func (t *DurableTree[K, V]) Find(key K) (V, bool) {
+	return t.tree.Find(key)
+}
+
+func (t *DurableTree[K, V]) Each(f func(K, V)) {
+	t.tree.Each(f)
+}
+
+// Insert logs the insertion of key and value and then makes it.
+func (t *DurableTree[K, V]) Insert(key K, value V) error {
+	record, err := t.encodeRecord(insertRecord, key, &value)
+	if err != nil {
+		return err
+	}
+
+	if err := t.append(record); err != nil {
+		return err
+	}
+
+	t.tree.Insert(key, value)
+	t.afterChange()
+	return nil
+}
+
+// Delete logs the removal of key and then makes it. Removing a key that is not
+// in the tree is not logged.
+func (t *DurableTree[K, V]) Delete(key K) (V, bool, error) {
+	var zeroValue V
+	if _, found := t.tree.Find(key); !found {
+		return zeroValue, false, nil
+	}
+
+	record, err := t.encodeRecord(deleteRecord, key, nil)
+	if err != nil {
+		return zeroValue, false, err
+	}
+
+	if err := t.append(record); err != nil {
+		return zeroValue, false, err
+	}
+
+	value, _ := t.tree.Delete(key)
+	t.afterChange()
+	return value, true, nil
+}
+
+// Sync syncs the log to stable storage.
+func (t *DurableTree[K, V]) Sync() error {
+	if err := t.log.Sync(); err != nil {
+		return err
+	}
+
+	t.unsynced = 0
+	return nil
+}
+
+// Checkpoint writes the whole tree to a new checkpoint and then empties the
+// log. A crash part way through leaves the previous checkpoint in place, and
+// replaying a log over a checkpoint that already includes its changes leaves
+// the tree the same.
+func (t *DurableTree[K, V]) Checkpoint() error {
+	path := filepath.Join(t.dir, checkpointFile)
+	tmpPath := path + ".tmp"
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := t.tree.Serializer(t.keys, t.values).WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	renamed = true
+
+	if err := syncDir(t.dir); err != nil {
+		return err
+	}
+
+	if err := t.log.Truncate(0); err != nil {
+		return err
+	}
+
+	t.logSize, t.logged, t.failed = 0, 0, nil
+	return t.Sync()
+}
+
+// CheckpointErr returns the error from the last automatic checkpoint, or nil
+// if it succeeded or there has not been one.
+func (t *DurableTree[K, V]) CheckpointErr() error {
+	return t.checkpointErr
+}
+
+// Close syncs the log and closes it.
+func (t *DurableTree[K, V]) Close() error {
+	if err := t.Sync(); err != nil {
+		t.log.Close()
+		return err
+	}
+	return t.log.Close()
+}
+
+func (t *DurableTree[K, V]) readCheckpoint() error {
+	file, err := os.Open(filepath.Join(t.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := t.tree.Serializer(t.keys, t.values).ReadFrom(bufio.NewReader(file)); err != nil {
+		return fmt.Errorf("btree: reading checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// replay applies the records in the log to the tree. It stops at the first
+// record that is incomplete or fails its checksum, which can only have been
+// torn by a crash while it was being appended, and cuts the log short there
+// so that new records follow on from the last good one.
+func (t *DurableTree[K, V]) replay() error {
+	data, err := io.ReadAll(t.log)
+	if err != nil {
+		return err
+	}
+
+	offset := 0
+	for offset < len(data) {
+		payload, ok := readRecord(data[offset:])
+		if !ok {
+			break
+		}
+
+		if err := t.apply(payload); err != nil {
+			return fmt.Errorf("btree: replaying log at offset %d: %w", offset, err)
+		}
+
+		offset += recordHeaderSize + len(payload)
+		t.logged += 1
+	}
+
+	t.logSize = int64(offset)
+	if offset < len(data) {
+		if err := t.log.Truncate(int64(offset)); err != nil {
+			return err
+		}
+		return t.Sync()
+	}
+
+	return nil
+}
+
+func (t *DurableTree[K, V]) apply(payload []byte) error {
+	key, rest, err := decodeLengthPrefixed(payload[1:], t.keys)
+	if err != nil {
+		return err
+	}
+
+	switch payload[0] {
+	case insertRecord:
+		value, _, err := decodeLengthPrefixed(rest, t.values)
+		if err != nil {
+			return err
+		}
+		t.tree.Insert(key, value)
+	case deleteRecord:
+		t.tree.Delete(key)
+	default:
+		return fmt.Errorf("unknown record kind %d", payload[0])
+	}
+
+	return nil
+}
+
+// encodeRecord lays out a record as the length of its payload, a CRC-32C
+// checksum of the payload and then the payload itself, which is the kind of
+// operation followed by a length-prefixed key and, for an insertion, a
+// length-prefixed value.
+func (t *DurableTree[K, V]) encodeRecord(kind byte, key K, value *V) ([]byte, error) {
+	record := make([]byte, recordHeaderSize, 64)
+	record = append(record, kind)
+
+	var err error
+	if record, err = appendLengthPrefixed(record, t.keys, key); err != nil {
+		return nil, err
+	}
+	if value != nil {
+		if record, err = appendLengthPrefixed(record, t.values, *value); err != nil {
+			return nil, err
+		}
+	}
+
+	payload := record[recordHeaderSize:]
+	binary.BigEndian.PutUint32(record[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.Checksum(payload, castagnoli))
+
+	return record, nil
+}
+
+// readRecord returns the payload of the record at the start of data, and false
+// if the record is incomplete or its checksum does not match.
+func readRecord(data []byte) ([]byte, bool) {
+	if len(data) < recordHeaderSize {
+		return nil, false
+	}
+
+	// Every payload holds at least the kind of operation, so a zero length is
+	// taken to be space the file grew by that was never written to.
+	length := binary.BigEndian.Uint32(data[0:])
+	checksum := binary.BigEndian.Uint32(data[4:])
+	if length == 0 || uint64(len(data)-recordHeaderSize) < uint64(length) {
+		return nil, false
+	}
+
+	payload := data[recordHeaderSize : recordHeaderSize+int(length)]
+	if crc32.Checksum(payload, castagnoli) != checksum {
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func (t *DurableTree[K, V]) append(record []byte) error {
+	if t.failed != nil {
+		return t.failed
+	}
+
+	if _, err := t.log.Write(record); err != nil {
+		return t.unappend(err)
+	}
+
+	t.unsynced += 1
+	if t.opts.Sync == SyncEveryOp || (t.opts.Sync == SyncBatched && t.unsynced >= t.opts.BatchSize) {
+		if err := t.Sync(); err != nil {
+			t.unsynced -= 1
+			return t.unappend(err)
+		}
+	}
+
+	t.logSize += int64(len(record))
+	return nil
+}
+
+// unappend cuts the log back to its last good record after appending a record
+// failed with err. Part of a record left behind would stop the log being
+// replayed past it, losing the changes appended after it, and a whole record
+// would bring back a change that was reported as failed.
+func (t *DurableTree[K, V]) unappend(err error) error {
+	if truncateErr := t.log.Truncate(t.logSize); truncateErr != nil {
+		t.failed = fmt.Errorf("%w: %w", ErrLogFailed, truncateErr)
+		return errors.Join(err, t.failed)
+	}
+	return err
+}
+
+// afterChange checkpoints the tree once enough changes have been logged. The
+// change is already in the log, so a failed checkpoint is kept for
+// CheckpointErr rather than returned.
+func (t *DurableTree[K, V]) afterChange() {
+	t.logged += 1
+	if t.opts.CheckpointEvery > 0 && t.logged >= t.opts.CheckpointEvery {
+		t.checkpointErr = t.Checkpoint()
+	}
+}
+
+// syncDir syncs a directory, so that files created or renamed in it survive a
+// crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// writeAheadLog is the file a DurableTree appends its log to.
+type writeAheadLog interface {
+	io.ReadWriteCloser
+	Sync() error
+	Truncate(size int64) error
+}
@@ -0,0 +1,160 @@
+package btree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurableTreeFaults(t *testing.T) {
+	errDisk := errors.New("disk full")
+
+	for name, fault := range map[string]faultyLog{
+		"a write that fails part way": {tornWrites: 1},
+		"a sync that fails":           {failSyncs: 1},
+	} {
+		t.Run(name+" is cut back out of the log", func(t *testing.T) {
+			// Given
+			dir := t.TempDir()
+			dt := openFaultyDurableTree(t, dir, fault, errDisk)
+			require.NoError(t, dt.Insert(1, "one"))
+			dt.log.(*faultyLog).arm()
+
+			// When
+			err := dt.Insert(2, "two")
+			require.NoError(t, dt.Insert(3, "three"))
+			require.NoError(t, dt.Close())
+
+			// Then
+			require.ErrorIs(t, err, errDisk)
+			reopened, openErr := OpenDurable(dir, BinaryCodec[int8]{}, StringCodec{}, nil)
+			require.NoError(t, openErr)
+			defer reopened.Close()
+			require.Equal(t, 2, reopened.Len())
+			_, found := reopened.Find(2)
+			require.False(t, found)
+		})
+	}
+
+	t.Run("it takes no more changes when the log cannot be cut back", func(t *testing.T) {
+		// Given
+		dir := t.TempDir()
+		dt := openFaultyDurableTree(t, dir, faultyLog{tornWrites: 1, failTruncates: true}, errDisk)
+		require.NoError(t, dt.Insert(1, "one"))
+		dt.log.(*faultyLog).arm()
+		require.ErrorIs(t, dt.Insert(2, "two"), errDisk)
+
+		// When
+		err := dt.Insert(3, "three")
+		_, _, deleteErr := dt.Delete(1)
+
+		// Then
+		require.ErrorIs(t, err, ErrLogFailed)
+		require.ErrorIs(t, deleteErr, ErrLogFailed)
+		require.Equal(t, 1, dt.Len())
+		require.NoError(t, dt.Checkpoint())
+		require.NoError(t, dt.Insert(3, "three"))
+		require.NoError(t, dt.Close())
+	})
+}
+
+func TestDurableTreeCheckpointFaults(t *testing.T) {
+	t.Run("a failed automatic checkpoint does not fail the change", func(t *testing.T) {
+		// Given a directory in the way of the checkpoint
+		dir := t.TempDir()
+		dt, err := OpenDurable(dir, BinaryCodec[int8]{}, StringCodec{}, &DurableOptions{CheckpointEvery: 2})
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "checkpoint", "blocker"), 0o755))
+		require.NoError(t, dt.Insert(1, "one"))
+
+		// When
+		err = dt.Insert(2, "two")
+
+		// Then
+		require.NoError(t, err)
+		require.Error(t, dt.CheckpointErr())
+		require.NoFileExists(t, filepath.Join(dir, "checkpoint.tmp"))
+
+		// And the checkpoint is tried again after the next change
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "checkpoint")))
+		require.NoError(t, dt.Insert(3, "three"))
+		require.NoError(t, dt.CheckpointErr())
+		require.NoError(t, dt.Close())
+
+		reopened, err := OpenDurable(dir, BinaryCodec[int8]{}, StringCodec{}, nil)
+		require.NoError(t, err)
+		defer reopened.Close()
+		require.Equal(t, 3, reopened.Len())
+	})
+
+	t.Run("a failed checkpoint leaves no temporary file behind", func(t *testing.T) {
+		// Given
+		dir := t.TempDir()
+		dt, err := OpenDurable(dir, BinaryCodec[int8]{}, StringCodec{}, &DurableOptions{CheckpointEvery: -1})
+		require.NoError(t, err)
+		defer dt.Close()
+		require.NoError(t, dt.Insert(1, "one"))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "checkpoint", "blocker"), 0o755))
+
+		// When
+		err = dt.Checkpoint()
+
+		// Then
+		require.Error(t, err)
+		require.NoFileExists(t, filepath.Join(dir, "checkpoint.tmp"))
+		value, found := dt.Find(1)
+		require.True(t, found)
+		require.Equal(t, "one", value)
+	})
+}
+
+// faultyLog is a log whose next writes, once armed, are torn, or whose next
+// syncs fail, with err.
+type faultyLog struct {
+	writeAheadLog
+	err           error
+	armed         bool
+	tornWrites    int
+	failSyncs     int
+	failTruncates bool
+}
+
+func (l *faultyLog) arm() {
+	l.armed = true
+}
+
+func (l *faultyLog) Write(p []byte) (int, error) {
+	if l.armed && l.tornWrites > 0 {
+		l.tornWrites -= 1
+		n, _ := l.writeAheadLog.Write(p[:len(p)/2])
+		return n, l.err
+	}
+	return l.writeAheadLog.Write(p)
+}
+
+func (l *faultyLog) Sync() error {
+	if l.armed && l.failSyncs > 0 {
+		l.failSyncs -= 1
+		return l.err
+	}
+	return l.writeAheadLog.Sync()
+}
+
+func (l *faultyLog) Truncate(size int64) error {
+	if l.armed && l.failTruncates {
+		l.failTruncates = false
+		return l.err
+	}
+	return l.writeAheadLog.Truncate(size)
+}
+
+func openFaultyDurableTree(t *testing.T, dir string, fault faultyLog, err error) *DurableTree[int8, string] {
+	dt, openErr := OpenDurable(dir, BinaryCodec[int8]{}, StringCodec{}, &DurableOptions{CheckpointEvery: -1})
+	require.NoError(t, openErr)
+	fault.writeAheadLog, fault.err = dt.log, err
+	dt.log = &fault
+	return dt
+}
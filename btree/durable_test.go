@@ -0,0 +1,188 @@
+package btree_test
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurableTree(t *testing.T) {
+	t.Run("changes survive closing and reopening the tree", func(t *testing.T) {
+		for name, policy := range map[string]btree.SyncPolicy{
+			"every op": btree.SyncEveryOp,
+			"batched":  btree.SyncBatched,
+			"never":    btree.SyncNever,
+		} {
+			propFunc := func(keys []int8) bool {
+				// Given
+				dir := t.TempDir()
+				opts := &btree.DurableOptions{Sync: policy, BatchSize: 3}
+				dt := openDurableTree(t, dir, opts)
+				oracle := make(map[int8]string)
+
+				// When
+				for i, key := range keys {
+					if _, ok := oracle[key]; ok && i%3 == 0 {
+						value, found, err := dt.Delete(key)
+						if !(assert.NoError(t, err, name) && assert.True(t, found, name) && assert.Equal(t, oracle[key], value, name)) {
+							return false
+						}
+						delete(oracle, key)
+					} else {
+						oracle[key] = strings.Repeat("v", i%5)
+						if !assert.NoError(t, dt.Insert(key, oracle[key]), name) {
+							return false
+						}
+					}
+				}
+				require.NoError(t, dt.Close())
+				dt = openDurableTree(t, dir, opts)
+				defer dt.Close()
+
+				// Then
+				return assert.Equal(t, oracle, collectDurableEntries(dt), name)
+			}
+
+			require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 100}))
+		}
+	})
+
+	t.Run("deleting a missing key is not logged", func(t *testing.T) {
+		// Given
+		dir := t.TempDir()
+		dt := openDurableTree(t, dir, nil)
+		defer dt.Close()
+
+		// When
+		_, found, err := dt.Delete(1)
+
+		// Then
+		require.NoError(t, err)
+		require.False(t, found)
+		require.Zero(t, fileSize(t, filepath.Join(dir, "wal")))
+	})
+
+	t.Run("a checkpoint empties the log", func(t *testing.T) {
+		// Given
+		dir := t.TempDir()
+		dt := openDurableTree(t, dir, &btree.DurableOptions{CheckpointEvery: -1})
+		for i := int8(0); i < 100; i += 1 {
+			require.NoError(t, dt.Insert(i, "v"))
+		}
+		require.NotZero(t, fileSize(t, filepath.Join(dir, "wal")))
+
+		// When
+		require.NoError(t, dt.Checkpoint())
+		_, _, err := dt.Delete(7)
+		require.NoError(t, err)
+		require.NoError(t, dt.Close())
+		dt = openDurableTree(t, dir, nil)
+		defer dt.Close()
+
+		// Then
+		require.Equal(t, 99, dt.Len())
+		_, found := dt.Find(7)
+		require.False(t, found)
+	})
+
+	t.Run("it checkpoints after the given number of changes", func(t *testing.T) {
+		// Given
+		dir := t.TempDir()
+		dt := openDurableTree(t, dir, &btree.DurableOptions{CheckpointEvery: 10})
+		defer dt.Close()
+
+		// When
+		for i := int8(0); i < 9; i += 1 {
+			require.NoError(t, dt.Insert(i, "v"))
+		}
+		beforeCheckpoint := fileSize(t, filepath.Join(dir, "wal"))
+		require.NoError(t, dt.Insert(9, "v"))
+
+		// Then
+		require.NotZero(t, beforeCheckpoint)
+		require.Zero(t, fileSize(t, filepath.Join(dir, "wal")))
+		require.NotZero(t, fileSize(t, filepath.Join(dir, "checkpoint")))
+	})
+
+	t.Run("a log torn by a crash recovers a prefix of the changes", func(t *testing.T) {
+		// Given
+		dir := t.TempDir()
+		dt := openDurableTree(t, dir, &btree.DurableOptions{CheckpointEvery: -1})
+		oracle := make(map[int8]string)
+		prefixes := []map[int8]string{maps.Clone(oracle)}
+		for i := int8(0); i < 20; i += 1 {
+			key := i % 7
+			if _, ok := oracle[key]; ok && i%2 == 0 {
+				_, _, err := dt.Delete(key)
+				require.NoError(t, err)
+				delete(oracle, key)
+			} else {
+				require.NoError(t, dt.Insert(key, strings.Repeat("v", int(i))))
+				oracle[key] = strings.Repeat("v", int(i))
+			}
+			prefixes = append(prefixes, maps.Clone(oracle))
+		}
+		require.NoError(t, dt.Close())
+		log, err := os.ReadFile(filepath.Join(dir, "wal"))
+		require.NoError(t, err)
+
+		lastPrefix := 0
+		for size := 0; size <= len(log); size += 1 {
+			// When
+			crashDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(crashDir, "wal"), log[:size], 0o644))
+			recovered := openDurableTree(t, crashDir, nil)
+			entries := collectDurableEntries(recovered)
+			require.NoError(t, recovered.Close())
+
+			// Then
+			prefix := lastPrefix
+			for prefix < len(prefixes) && !assert.ObjectsAreEqual(prefixes[prefix], entries) {
+				prefix += 1
+			}
+			require.Less(t, prefix, len(prefixes), "size=%d", size)
+			lastPrefix = prefix
+		}
+		require.Equal(t, len(prefixes)-1, lastPrefix)
+	})
+
+	t.Run("it appends after the last good record of a torn log", func(t *testing.T) {
+		// Given
+		dir := t.TempDir()
+		dt := openDurableTree(t, dir, nil)
+		require.NoError(t, dt.Insert(1, "one"))
+		require.NoError(t, dt.Insert(2, "two"))
+		require.NoError(t, dt.Close())
+		path := filepath.Join(dir, "wal")
+		require.NoError(t, os.Truncate(path, fileSize(t, path)-1))
+
+		// When
+		dt = openDurableTree(t, dir, nil)
+		require.NoError(t, dt.Insert(3, "three"))
+		require.NoError(t, dt.Close())
+		dt = openDurableTree(t, dir, nil)
+		defer dt.Close()
+
+		// Then
+		require.Equal(t, map[int8]string{1: "one", 3: "three"}, collectDurableEntries(dt))
+	})
+}
+
+func openDurableTree(t *testing.T, dir string, opts *btree.DurableOptions) *btree.DurableTree[int8, string] {
+	dt, err := btree.OpenDurable(dir, btree.BinaryCodec[int8]{}, btree.StringCodec{}, opts)
+	require.NoError(t, err)
+	return dt
+}
+
+func collectDurableEntries(dt *btree.DurableTree[int8, string]) map[int8]string {
+	entries := make(map[int8]string)
+	dt.Each(func(key int8, value string) { entries[key] = value })
+	return entries
+}
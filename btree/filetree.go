@@ -0,0 +1,501 @@
+package btree
+
+import (
+	"cmp"
+	"fmt"
+
+	"golang.org/x/exp/constraints"
+)
+
+const (
+	defaultBlockSize = 4096
+	defaultFileK     = 16
+	defaultPoolSize  = 64
+
+	// pageHeaderSize is the space taken by the kind and number of entries at
+	// the start of every page block.
+	pageHeaderSize = 1 + 2
+
+	// minEntrySize is the least space an entry must be allowed in a page for a
+	// block size and order to be usable.
+	minEntrySize = 8
+)
+
+// FileOptions configures a FileTree when its file is first created. A file
+// that already exists keeps the block size and order it was created with.
+type FileOptions struct {
+	// BlockSize is the size in bytes of each page on disk. Defaults to 4096.
+	BlockSize int
+
+	// Order is the order k of the tree. Defaults to 16.
+	Order int
+
+	// PoolSize is the number of pages cached in memory. Defaults to 64.
+	PoolSize int
+}
+
+func (o *FileOptions) withDefaults() FileOptions {
+	var opts FileOptions
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.BlockSize == 0 {
+		opts.BlockSize = defaultBlockSize
+	}
+	if opts.Order == 0 {
+		opts.Order = defaultFileK
+	}
+	if opts.PoolSize == 0 {
+		opts.PoolSize = defaultPoolSize
+	}
+
+	return opts
+}
+
+// FileTree is a B-tree stored in a single file as fixed-size blocks, one page
+// per block. Pages are read into a buffer pool as they are needed and written
+// back when they are evicted, or when the tree is flushed or closed.
+//
+// Changes are only guaranteed to be on disk once Flush or Close has returned.
+// The blocks of deleted pages are not reused until then, but changed pages
+// evicted from the buffer pool are written back over the blocks they were read
+// from, as is every changed page during a flush. So a crash before a flush has
+// finished can leave the file holding a mix of old and new pages, which may
+// fail to open with ErrCorrupt or hold a damaged tree. A pool large enough to
+// hold every page changed between flushes leaves the file as it was at the
+// last flush until the next one starts.
+type FileTree[K any, V any] struct {
+	pager   *pager
+	pool    *bufferPool[K, V]
+	order   order
+	compare func(a, b K) int
+
+	// maxEntrySize is the most space an encoded entry may take, chosen so that
+	// a full page always fits in a block.
+	maxEntrySize int
+}
+
+type fileFrame[K any, V any] struct {
+	page  *filePage[K, V]
+	index int
+}
+
+// OpenFile opens the tree stored in the file at path, creating the file if it
+// does not exist. Keys and values are written to disk using the given codecs.
+func OpenFile[K constraints.Ordered, V any](path string, keys Codec[K], values Codec[V], opts *FileOptions) (*FileTree[K, V], error) {
+	return OpenFileFunc(path, cmp.Compare[K], keys, values, opts)
+}
+
+// OpenFileFunc is like OpenFile but orders the keys using compare.
+func OpenFileFunc[K any, V any](path string, compare func(a, b K) int, keys Codec[K], values Codec[V], opts *FileOptions) (*FileTree[K, V], error) {
+	o := opts.withDefaults()
+	if _, _, err := fileLayout(o.BlockSize, o.Order); err != nil {
+		return nil, err
+	}
+
+	pg, err := openPager(path, o.BlockSize, o.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	ord, maxEntrySize, err := fileLayout(pg.blockSize(), int(pg.header.k))
+	if err != nil {
+		pg.close()
+		return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	return &FileTree[K, V]{
+		pager:        pg,
+		pool:         newBufferPool(pg, ord, keys, values, o.PoolSize),
+		order:        ord,
+		compare:      compare,
+		maxEntrySize: maxEntrySize,
+	}, nil
+}
+
+// fileLayout works out the order and the largest entry that fits a page of
+// blockSize bytes holding up to 2k entries and 2k+1 child block ids.
+func fileLayout(blockSize int, k int) (order, int, error) {
+	if k < 1 {
+		return order{}, 0, fmt.Errorf("btree: order must be at least 1, got %d", k)
+	}
+
+	o := newOrder(k)
+	maxEntrySize := (blockSize - pageHeaderSize - 4*o.maxChildren) / o.maxEntries
+
+	if maxEntrySize < minEntrySize {
+		return order{}, 0, fmt.Errorf("btree: block size %d is too small for order %d", blockSize, k)
+	}
+
+	return o, maxEntrySize, nil
+}
+
+func (t *FileTree[K, V]) Len() int {
+	return int(t.pager.header.numEntries)
+}
+
+func (t *FileTree[K, V]) Insert(key K, value V) (err error) {
+	defer t.trim(&err)
+
+	if err := t.checkEntrySize(key, value); err != nil {
+		return err
+	}
+
+	if t.pager.header.root == 0 {
+		root, err := t.pool.allocate()
+		if err != nil {
+			return err
+		}
+		t.pager.header.root = root.id
+	}
+
+	path, found, err := t.descend(key)
+	if err != nil {
+		return err
+	}
+
+	top := path[len(path)-1]
+	top.page.dirty = true
+
+	if found {
+		top.page.entries[top.index].value = value
+		return nil
+	}
+
+	spares, err := t.allocateSplits(path)
+	if err != nil {
+		return err
+	}
+
+	top.page.entries.insertAt(top.index, entry[K, V]{key: key, value: value})
+	t.pager.header.numEntries += 1
+
+	for d := len(path) - 1; d >= 0 && len(path[d].page.entries) > t.order.maxEntries; d -= 1 {
+		var newRoot *filePage[K, V]
+		if d == 0 {
+			newRoot = spares[1]
+		}
+		t.split(path, d, spares[0], newRoot)
+		spares = spares[1:]
+	}
+
+	return nil
+}
+
+// allocateSplits allocates the pages needed to split the full pages at the
+// bottom of path, and a new root if every page on it is full. They are
+// allocated before an entry is inserted so that failing to allocate one
+// leaves every page as it was, and any allocated before the failure are freed.
+func (t *FileTree[K, V]) allocateSplits(path []fileFrame[K, V]) ([]*filePage[K, V], error) {
+	n := 0
+	for d := len(path) - 1; d >= 0 && len(path[d].page.entries) == t.order.maxEntries; d -= 1 {
+		n += 1
+	}
+	if n == len(path) {
+		n += 1
+	}
+
+	spares := make([]*filePage[K, V], 0, n)
+	for range n {
+		p, err := t.pool.allocate()
+		if err != nil {
+			for _, spare := range spares {
+				t.pool.free(spare)
+			}
+			return nil, err
+		}
+		spares = append(spares, p)
+	}
+
+	return spares, nil
+}
+
+// split moves the upper half of the overflowing page at depth d of path to
+// newRight and its middle entry up to its parent, which is newRoot if d is 0.
+func (t *FileTree[K, V]) split(path []fileFrame[K, V], d int, newRight, newRoot *filePage[K, V]) {
+	p := path[d].page
+
+	var parent *filePage[K, V]
+	var i int
+
+	if d == 0 {
+		newRoot.children = make(items[blockID], 0, t.order.maxChildren+1)
+		newRoot.children.add(p.id)
+		t.pager.header.root = newRoot.id
+		parent, i = newRoot, 0
+	} else {
+		parent, i = path[d-1].page, path[d-1].index
+	}
+
+	newRight.entries = append(newRight.entries, p.entries[t.order.minEntries+1:]...)
+	p.entries.truncate(t.order.minEntries + 1)
+
+	if !p.isLeaf() {
+		newRight.children = make(items[blockID], 0, t.order.maxChildren+1)
+		newRight.children = append(newRight.children, p.children[t.order.minChildren+1:]...)
+		p.children.truncate(t.order.minChildren + 1)
+	}
+
+	parent.entries.insertAt(i, p.entries.pop())
+	parent.children.insertAt(i+1, newRight.id)
+
+	p.dirty = true
+	parent.dirty = true
+}
+
+func (t *FileTree[K, V]) Find(key K) (value V, found bool, err error) {
+	defer t.trim(&err)
+
+	if t.pager.header.root == 0 {
+		return value, false, nil
+	}
+
+	path, found, err := t.descend(key)
+	if err != nil || !found {
+		return value, false, err
+	}
+
+	top := path[len(path)-1]
+	return top.page.entries[top.index].value, true, nil
+}
+
+func (t *FileTree[K, V]) Delete(key K) (value V, found bool, err error) {
+	defer t.trim(&err)
+
+	if t.pager.header.root == 0 {
+		return value, false, nil
+	}
+
+	path, found, err := t.descend(key)
+	if err != nil || !found {
+		return value, false, err
+	}
+
+	top := path[len(path)-1]
+	p := top.page
+	value = p.entries[top.index].value
+	p.dirty = true
+
+	if p.isLeaf() {
+		p.entries.removeAt(top.index)
+	} else {
+		// Replace the entry with its in-order predecessor from the rightmost
+		// leaf of its left subtree, extending the path down to that leaf.
+		id := p.children[top.index]
+		for {
+			q, err := t.pool.get(id)
+			if err != nil {
+				return value, false, err
+			}
+
+			if q.isLeaf() {
+				path = append(path, fileFrame[K, V]{page: q, index: len(q.entries) - 1})
+				p.entries[top.index] = q.entries.pop()
+				q.dirty = true
+				break
+			}
+
+			path = append(path, fileFrame[K, V]{page: q, index: len(q.children) - 1})
+			id = *q.children.last()
+		}
+	}
+
+	t.pager.header.numEntries -= 1
+
+	return value, true, t.rebalance(path)
+}
+
+// rebalance restores the minimum number of entries of the pages along path
+// after an entry has been removed from the page at the end of it.
+func (t *FileTree[K, V]) rebalance(path []fileFrame[K, V]) error {
+	for d := len(path) - 1; d > 0; d -= 1 {
+		p := path[d].page
+		if len(p.entries) >= t.order.minEntries {
+			return nil
+		}
+
+		parent, i := path[d-1].page, path[d-1].index
+
+		var left, right *filePage[K, V]
+		var err error
+
+		if i > 0 {
+			if left, err = t.pool.get(parent.children[i-1]); err != nil {
+				return err
+			}
+			if len(left.entries) > t.order.minEntries {
+				t.borrowFromLeft(parent, i, left, p)
+				return nil
+			}
+		}
+
+		if i < len(parent.children)-1 {
+			if right, err = t.pool.get(parent.children[i+1]); err != nil {
+				return err
+			}
+			if len(right.entries) > t.order.minEntries {
+				t.borrowFromRight(parent, i, p, right)
+				return nil
+			}
+		}
+
+		if left != nil {
+			t.merge(parent, i-1, left, p)
+		} else {
+			t.merge(parent, i, p, right)
+		}
+	}
+
+	root := path[0].page
+	if len(root.entries) > 0 {
+		return nil
+	}
+
+	if root.isLeaf() {
+		t.pager.header.root = 0
+	} else {
+		t.pager.header.root = root.children[0]
+	}
+
+	t.pool.free(root)
+	return nil
+}
+
+func (t *FileTree[K, V]) borrowFromLeft(parent *filePage[K, V], i int, left, p *filePage[K, V]) {
+	p.entries.insertAt(0, parent.entries[i-1])
+	parent.entries[i-1] = left.entries.pop()
+
+	if !left.isLeaf() {
+		p.children.insertAt(0, left.children.pop())
+	}
+
+	parent.dirty, left.dirty, p.dirty = true, true, true
+}
+
+func (t *FileTree[K, V]) borrowFromRight(parent *filePage[K, V], i int, p, right *filePage[K, V]) {
+	p.entries.add(parent.entries[i])
+	parent.entries[i] = right.entries.removeAt(0)
+
+	if !right.isLeaf() {
+		p.children.add(right.children.removeAt(0))
+	}
+
+	parent.dirty, right.dirty, p.dirty = true, true, true
+}
+
+// merge combines parent.children[i], the parent entry separating it from its
+// right sibling and that right sibling into the left page, releasing the block
+// of the right page.
+func (t *FileTree[K, V]) merge(parent *filePage[K, V], i int, left, right *filePage[K, V]) {
+	left.entries.add(parent.entries.removeAt(i))
+	left.entries = append(left.entries, right.entries...)
+	left.children = append(left.children, right.children...)
+	parent.children.removeAt(i + 1)
+
+	parent.dirty, left.dirty = true, true
+
+	t.pool.free(right)
+}
+
+// Each calls f for every entry in ascending key order.
+func (t *FileTree[K, V]) Each(f func(K, V)) (err error) {
+	defer t.trim(&err)
+
+	if t.pager.header.root == 0 {
+		return nil
+	}
+
+	return t.traverseSubtree(t.pager.header.root, f)
+}
+
+func (t *FileTree[K, V]) traverseSubtree(id blockID, f func(K, V)) error {
+	p, err := t.pool.get(id)
+	if err != nil {
+		return err
+	}
+
+	// Nothing is modified during a traversal, so the pool can be trimmed as it
+	// goes rather than holding the whole tree in memory.
+	if err := t.pool.trim(); err != nil {
+		return err
+	}
+
+	for i, e := range p.entries {
+		if !p.isLeaf() {
+			if err := t.traverseSubtree(p.children[i], f); err != nil {
+				return err
+			}
+		}
+		f(e.key, e.value)
+	}
+
+	if !p.isLeaf() {
+		return t.traverseSubtree(*p.children.last(), f)
+	}
+
+	return nil
+}
+
+// Flush writes every changed page and the file header to disk and syncs the
+// file.
+func (t *FileTree[K, V]) Flush() error {
+	return t.pool.flush()
+}
+
+// Close flushes the tree and closes its file.
+func (t *FileTree[K, V]) Close() error {
+	if err := t.Flush(); err != nil {
+		t.pager.close()
+		return err
+	}
+	return t.pager.close()
+}
+
+// descend returns the path of pages from the root to the page holding key, or
+// the leaf where it should be inserted, along with the index of the child
+// descended into at each page or the index of key itself in the last page.
+func (t *FileTree[K, V]) descend(key K) ([]fileFrame[K, V], bool, error) {
+	path := make([]fileFrame[K, V], 0, 8)
+
+	id := t.pager.header.root
+	for {
+		p, err := t.pool.get(id)
+		if err != nil {
+			return nil, false, err
+		}
+
+		i, found := searchEntries(p.entries, key, t.compare)
+		path = append(path, fileFrame[K, V]{page: p, index: i})
+
+		if found || p.isLeaf() {
+			return path, found, nil
+		}
+
+		id = p.children[i]
+	}
+}
+
+func (t *FileTree[K, V]) checkEntrySize(key K, value V) error {
+	buf, err := appendLengthPrefixed(nil, t.pool.keys, key)
+	if err != nil {
+		return err
+	}
+
+	buf, err = appendLengthPrefixed(buf, t.pool.values, value)
+	if err != nil {
+		return err
+	}
+
+	if len(buf) > t.maxEntrySize {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrEntryTooLarge, len(buf), t.maxEntrySize)
+	}
+
+	return nil
+}
+
+func (t *FileTree[K, V]) trim(err *error) {
+	if trimErr := t.pool.trim(); *err == nil {
+		*err = trimErr
+	}
+}
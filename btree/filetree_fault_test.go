@@ -0,0 +1,62 @@
+package btree
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTreeFaults(t *testing.T) {
+	t.Run("a split that cannot allocate a block leaves every page as it was", func(t *testing.T) {
+		// Given a full root leaf, and a free list whose second block cannot be
+		// read
+		path := filepath.Join(t.TempDir(), "tree.db")
+		ft, err := OpenFile(path, BinaryCodec[int64]{}, StringCodec{}, &FileOptions{BlockSize: 256, Order: 1})
+		require.NoError(t, err)
+		require.NoError(t, ft.Insert(1, "one"))
+		require.NoError(t, ft.Insert(2, "two"))
+		breakFreeList(t, ft.pager)
+
+		// When the insertion needs a new right page and a new root
+		err = ft.Insert(3, "three")
+
+		// Then
+		require.ErrorIs(t, err, ErrCorrupt)
+		require.Equal(t, 2, ft.Len())
+		root, err := ft.pool.get(ft.pager.header.root)
+		require.NoError(t, err)
+		require.True(t, root.isLeaf())
+		require.Equal(t, []int64{1, 2}, []int64{root.entries[0].key, root.entries[1].key})
+
+		// And once the free list is mended the tree takes the entry
+		ft.pager.header.freeHead = 0
+		require.NoError(t, ft.Insert(3, "three"))
+		require.NoError(t, ft.Close())
+
+		reopened, err := OpenFile(path, BinaryCodec[int64]{}, StringCodec{}, nil)
+		require.NoError(t, err)
+		defer reopened.Close()
+		require.Equal(t, 3, reopened.Len())
+		for key := int64(1); key <= 3; key += 1 {
+			_, found, err := reopened.Find(key)
+			require.NoError(t, err)
+			require.True(t, found, "key=%d", key)
+		}
+	})
+}
+
+// breakFreeList puts a new free block at the head of the free list, followed
+// by a block beyond the end of the file, so that the first allocation succeeds
+// and the second fails with ErrCorrupt.
+func breakFreeList(t *testing.T, pg *pager) {
+	id, err := pg.allocate()
+	require.NoError(t, err)
+
+	block := make([]byte, pg.blockSize())
+	block[0] = freeBlock
+	binary.BigEndian.PutUint32(block[1:], pg.header.numBlocks)
+	require.NoError(t, pg.write(id, block))
+	pg.header.freeHead = id
+}
@@ -0,0 +1,214 @@
+package btree_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTree(t *testing.T) {
+	t.Run("entries survive closing and reopening the file", func(t *testing.T) {
+		// Given
+		path := filepath.Join(t.TempDir(), "tree.db")
+		ft := openFileTree(t, path, nil)
+		for i := 0; i < 1000; i += 1 {
+			require.NoError(t, ft.Insert(i, strings.Repeat("v", i%20)))
+		}
+		require.NoError(t, ft.Close())
+
+		// When
+		ft = openFileTree(t, path, nil)
+		defer ft.Close()
+
+		// Then
+		require.Equal(t, 1000, ft.Len())
+		for i := 0; i < 1000; i += 1 {
+			value, found, err := ft.Find(i)
+			require.NoError(t, err)
+			require.True(t, found, "key=%d", i)
+			require.Equal(t, strings.Repeat("v", i%20), value)
+		}
+	})
+
+	t.Run("it behaves like a map however small the buffer pool", func(t *testing.T) {
+		propFunc := func(keys []uint16, reopenAt uint8) bool {
+			// Given
+			path := filepath.Join(t.TempDir(), "tree.db")
+			opts := &btree.FileOptions{BlockSize: 256, Order: 2, PoolSize: 2}
+			ft := openFileTree(t, path, opts)
+			oracle := make(map[int]string)
+
+			for i, key := range keys {
+				// When
+				k := int(key % 64)
+				if _, ok := oracle[k]; ok && i%3 == 0 {
+					value, found, err := ft.Delete(k)
+					if !(assert.NoError(t, err) && assert.True(t, found) && assert.Equal(t, oracle[k], value)) {
+						return false
+					}
+					delete(oracle, k)
+				} else {
+					oracle[k] = strings.Repeat("x", i%10)
+					if !assert.NoError(t, ft.Insert(k, oracle[k])) {
+						return false
+					}
+				}
+
+				if i == int(reopenAt) {
+					require.NoError(t, ft.Close())
+					ft = openFileTree(t, path, opts)
+				}
+			}
+
+			// Then
+			defer ft.Close()
+			collected := make(map[int]string)
+			prev := -1
+			err := ft.Each(func(key int, value string) {
+				assert.Less(t, prev, key)
+				prev = key
+				collected[key] = value
+			})
+
+			return assert.NoError(t, err) && assert.Equal(t, oracle, collected) && assert.Equal(t, len(oracle), ft.Len())
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 200}))
+	})
+
+	t.Run("the blocks of deleted pages are reused after a flush", func(t *testing.T) {
+		// Given
+		path := filepath.Join(t.TempDir(), "tree.db")
+		opts := &btree.FileOptions{BlockSize: 256, Order: 2}
+		ft := openFileTree(t, path, opts)
+		defer ft.Close()
+
+		for i := 0; i < 500; i += 1 {
+			require.NoError(t, ft.Insert(i, "value"))
+		}
+		require.NoError(t, ft.Flush())
+		sizeBefore := fileSize(t, path)
+
+		// When
+		for i := 0; i < 500; i += 1 {
+			_, found, err := ft.Delete(i)
+			require.NoError(t, err)
+			require.True(t, found)
+		}
+		require.NoError(t, ft.Flush())
+		for i := 0; i < 500; i += 1 {
+			require.NoError(t, ft.Insert(i, "value"))
+		}
+		require.NoError(t, ft.Flush())
+
+		// Then
+		require.Equal(t, sizeBefore, fileSize(t, path))
+	})
+
+	t.Run("deletions before a crash leave the file as it was at the last flush", func(t *testing.T) {
+		// Given a pool large enough that no pages are evicted
+		path := filepath.Join(t.TempDir(), "tree.db")
+		opts := &btree.FileOptions{BlockSize: 256, Order: 2, PoolSize: 1000}
+		ft := openFileTree(t, path, opts)
+		defer ft.Close()
+		for i := 0; i < 100; i += 1 {
+			require.NoError(t, ft.Insert(i, "value"))
+		}
+		require.NoError(t, ft.Flush())
+
+		// When deleting every entry frees every block, and then the process
+		// crashes
+		for i := 0; i < 100; i += 1 {
+			_, _, err := ft.Delete(i)
+			require.NoError(t, err)
+		}
+		crashed, err := os.ReadFile(path)
+		require.NoError(t, err)
+		crashPath := filepath.Join(t.TempDir(), "crashed.db")
+		require.NoError(t, os.WriteFile(crashPath, crashed, 0o644))
+		recovered := openFileTree(t, crashPath, opts)
+		defer recovered.Close()
+
+		// Then
+		require.Equal(t, 100, recovered.Len())
+		for i := 0; i < 100; i += 1 {
+			value, found, err := recovered.Find(i)
+			require.NoError(t, err)
+			require.True(t, found, "key=%d", i)
+			require.Equal(t, "value", value)
+		}
+	})
+
+	t.Run("it rejects entries too large to fit in a page", func(t *testing.T) {
+		// Given
+		ft := openFileTree(t, filepath.Join(t.TempDir(), "tree.db"), &btree.FileOptions{BlockSize: 512, Order: 4})
+		defer ft.Close()
+
+		// When
+		err := ft.Insert(1, strings.Repeat("x", 512))
+
+		// Then
+		require.ErrorIs(t, err, btree.ErrEntryTooLarge)
+		require.Zero(t, ft.Len())
+	})
+
+	t.Run("it rejects a block size too small for the order", func(t *testing.T) {
+		// When
+		_, err := btree.OpenFile(filepath.Join(t.TempDir(), "tree.db"), btree.BinaryCodec[int64]{}, btree.StringCodec{},
+			&btree.FileOptions{BlockSize: 64, Order: 16})
+
+		// Then
+		require.Error(t, err)
+	})
+
+	t.Run("it rejects a file that is not a tree", func(t *testing.T) {
+		// Given
+		path := filepath.Join(t.TempDir(), "tree.db")
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("not a tree", 100)), 0o644))
+
+		// When
+		_, err := btree.OpenFile(path, btree.BinaryCodec[int64]{}, btree.StringCodec{}, nil)
+
+		// Then
+		require.ErrorIs(t, err, btree.ErrCorrupt)
+	})
+}
+
+// intFileTree adapts a file tree of int64 keys to int keys.
+type intFileTree struct {
+	*btree.FileTree[int64, string]
+}
+
+func (ft intFileTree) Insert(key int, value string) error {
+	return ft.FileTree.Insert(int64(key), value)
+}
+
+func (ft intFileTree) Find(key int) (string, bool, error) {
+	return ft.FileTree.Find(int64(key))
+}
+
+func (ft intFileTree) Delete(key int) (string, bool, error) {
+	return ft.FileTree.Delete(int64(key))
+}
+
+func (ft intFileTree) Each(f func(int, string)) error {
+	return ft.FileTree.Each(func(key int64, value string) { f(int(key), value) })
+}
+
+func openFileTree(t *testing.T, path string, opts *btree.FileOptions) intFileTree {
+	ft, err := btree.OpenFile(path, btree.BinaryCodec[int64]{}, btree.StringCodec{}, opts)
+	require.NoError(t, err)
+	return intFileTree{ft}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
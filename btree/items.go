@@ -1,7 +1,5 @@
 package btree
 
-import "golang.org/x/exp/slices"
-
 type items[E any] []E
 
 func (self items[E]) first() *E {
@@ -27,12 +25,25 @@ func (self *items[E]) pop() E {
 	return elem
 }
 
-func (self items[E]) sort(less func(a E, b E) bool) {
-	slices.SortFunc(self, less)
+func (self *items[E]) insertAt(index int, elem E) {
+	*self = append(*self, elem)
+	copy((*self)[index+1:], (*self)[index:])
+	(*self)[index] = elem
 }
 
-func (src *items[E]) splitAt(fromIndex int) items[E] {
-	ret := (*src)[fromIndex:]
-	*src = (*src)[:fromIndex:fromIndex]
-	return ret
+func (self *items[E]) removeAt(index int) E {
+	elem := (*self)[index]
+	copy((*self)[index:], (*self)[index+1:])
+	var zero E
+	(*self)[len(*self)-1] = zero
+	*self = (*self)[:len(*self)-1]
+	return elem
+}
+
+func (self *items[E]) truncate(length int) {
+	var zero E
+	for i := length; i < len(*self); i += 1 {
+		(*self)[i] = zero
+	}
+	*self = (*self)[:length]
 }
@@ -0,0 +1,112 @@
+package btree
+
+// Min returns the entry with the smallest key. It reports false when the tree
+// is empty.
+func (t *Tree[K, V]) Min() (K, V, bool) {
+	if t.root == nil {
+		return entryResult[K, V](nil)
+	}
+
+	p := t.root
+	for !p.isLeaf() {
+		p = p.children[0]
+	}
+	return entryResult(&p.entries[0])
+}
+
+// Max returns the entry with the largest key. It reports false when the tree
+// is empty.
+func (t *Tree[K, V]) Max() (K, V, bool) {
+	if t.root == nil {
+		return entryResult[K, V](nil)
+	}
+
+	p := t.root
+	for !p.isLeaf() {
+		p = p.children[len(p.children)-1]
+	}
+	return entryResult(&p.entries[len(p.entries)-1])
+}
+
+// Floor returns the entry with the greatest key less than or equal to key.
+func (t *Tree[K, V]) Floor(key K) (K, V, bool) {
+	return entryResult(t.below(key, false))
+}
+
+// Lower returns the entry with the greatest key strictly less than key.
+func (t *Tree[K, V]) Lower(key K) (K, V, bool) {
+	return entryResult(t.below(key, true))
+}
+
+// Ceiling returns the entry with the least key greater than or equal to key.
+func (t *Tree[K, V]) Ceiling(key K) (K, V, bool) {
+	return entryResult(t.above(key, false))
+}
+
+// Higher returns the entry with the least key strictly greater than key.
+func (t *Tree[K, V]) Higher(key K) (K, V, bool) {
+	return entryResult(t.above(key, true))
+}
+
+// below descends towards key, remembering the greatest entry passed on the
+// way that is less than key, or equal to it unless strict is set. The last one
+// remembered is the closest, as each step narrows the range of keys below it.
+// In a multi tree, it is the last of any entries with equal keys.
+func (t *Tree[K, V]) below(key K, strict bool) *entry[K, V] {
+	compare := t.searchFunc(!strict)
+
+	var closest *entry[K, V]
+	for p := t.root; p != nil; {
+		i, found := p.search(key, compare)
+		if found && !strict {
+			return &p.entries[i]
+		}
+
+		if i > 0 {
+			closest = &p.entries[i-1]
+		}
+
+		if p.isLeaf() {
+			break
+		}
+		p = p.children[i]
+	}
+	return closest
+}
+
+// above is the mirror image of below, remembering the least entry passed on
+// the way that is greater than key, or equal to it unless strict is set. In a
+// multi tree, it is the first of any entries with equal keys.
+func (t *Tree[K, V]) above(key K, strict bool) *entry[K, V] {
+	compare := t.searchFunc(strict)
+
+	var closest *entry[K, V]
+	for p := t.root; p != nil; {
+		i, found := p.search(key, compare)
+		if found {
+			if !strict {
+				return &p.entries[i]
+			}
+			i += 1
+		}
+
+		if i < len(p.entries) {
+			closest = &p.entries[i]
+		}
+
+		if p.isLeaf() {
+			break
+		}
+		p = p.children[i]
+	}
+	return closest
+}
+
+func entryResult[K any, V any](e *entry[K, V]) (K, V, bool) {
+	if e == nil {
+		var zeroKey K
+		var zeroValue V
+		return zeroKey, zeroValue, false
+	}
+	return e.key, e.value, true
+}
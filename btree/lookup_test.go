@@ -0,0 +1,155 @@
+package btree_test
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBTreeMinMax(t *testing.T) {
+	t.Run("it reports false when the tree is empty", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+
+		// When
+		_, _, minFound := bt.Min()
+		_, _, maxFound := bt.Max()
+
+		// Then
+		require.False(t, minFound)
+		require.False(t, maxFound)
+	})
+
+	t.Run("it returns the entries with the smallest and largest keys", func(t *testing.T) {
+		propFunc := func(first int8, keys []int8) bool {
+			// Given
+			keys = append(keys, first)
+			bt := newBTreeFrom(keys)
+			expected := cloneSortAndCompact(keys)
+
+			// When
+			minKey, minValue, minFound := bt.Min()
+			maxKey, maxValue, maxFound := bt.Max()
+
+			// Then
+			return assert.True(t, minFound) &&
+				assert.Equal(t, expected[0], minKey) &&
+				assert.Equal(t, expected[0], minValue) &&
+				assert.True(t, maxFound) &&
+				assert.Equal(t, expected[len(expected)-1], maxKey) &&
+				assert.Equal(t, expected[len(expected)-1], maxValue)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+}
+
+func TestBTreeNearest(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		lookup func(bt *btree.Tree[int8, int8], key int8) (int8, int8, bool)
+		oracle func(sorted []int8, key int8) (int8, bool)
+	}{
+		{
+			name:   "Floor",
+			lookup: (*btree.Tree[int8, int8]).Floor,
+			oracle: func(sorted []int8, key int8) (int8, bool) {
+				return lastOf(filterKeys(sorted, func(k int8) bool { return k <= key }))
+			},
+		},
+		{
+			name:   "Lower",
+			lookup: (*btree.Tree[int8, int8]).Lower,
+			oracle: func(sorted []int8, key int8) (int8, bool) {
+				return lastOf(filterKeys(sorted, func(k int8) bool { return k < key }))
+			},
+		},
+		{
+			name:   "Ceiling",
+			lookup: (*btree.Tree[int8, int8]).Ceiling,
+			oracle: func(sorted []int8, key int8) (int8, bool) {
+				return firstOf(filterKeys(sorted, func(k int8) bool { return k >= key }))
+			},
+		},
+		{
+			name:   "Higher",
+			lookup: (*btree.Tree[int8, int8]).Higher,
+			oracle: func(sorted []int8, key int8) (int8, bool) {
+				return firstOf(filterKeys(sorted, func(k int8) bool { return k > key }))
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("it reports false when the tree is empty", func(t *testing.T) {
+				// Given
+				bt := btree.New[int8, int8]()
+
+				// When
+				_, _, found := tc.lookup(&bt, 1)
+
+				// Then
+				require.False(t, found)
+			})
+
+			t.Run("it agrees with a sorted slice", func(t *testing.T) {
+				propFunc := func(keys []int8, key int8) bool {
+					// Given
+					bt := newBTreeFrom(keys)
+
+					// When
+					gotKey, gotValue, found := tc.lookup(&bt, key)
+
+					// Then
+					expected, expectedFound := tc.oracle(cloneSortAndCompact(keys), key)
+					return assert.Equal(t, expectedFound, found, "key=%d", key) &&
+						assert.Equal(t, expected, gotKey, "key=%d", key) &&
+						assert.Equal(t, expected, gotValue, "key=%d", key)
+				}
+
+				require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+			})
+
+			t.Run("it finds keys that are present", func(t *testing.T) {
+				propFunc := func(keys []int8) bool {
+					// Given
+					bt := newBTreeFrom(keys)
+					sorted := cloneSortAndCompact(keys)
+
+					for _, key := range sorted {
+						// When
+						gotKey, _, found := tc.lookup(&bt, key)
+
+						// Then
+						expected, expectedFound := tc.oracle(sorted, key)
+						if !(assert.Equal(t, expectedFound, found, "key=%d", key) && assert.Equal(t, expected, gotKey, "key=%d", key)) {
+							return false
+						}
+					}
+
+					return true
+				}
+
+				require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+			})
+		})
+	}
+}
+
+func firstOf[T any](s []T) (T, bool) {
+	var zero T
+	if len(s) == 0 {
+		return zero, false
+	}
+	return s[0], true
+}
+
+func lastOf[T any](s []T) (T, bool) {
+	var zero T
+	if len(s) == 0 {
+		return zero, false
+	}
+	return s[len(s)-1], true
+}
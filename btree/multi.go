@@ -0,0 +1,115 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+
+	"golang.org/x/exp/constraints"
+)
+
+// NewMulti returns an empty multi tree, in which a key can have any number of
+// entries. Entries with equal keys are kept in the order they were added.
+func NewMulti[K constraints.Ordered, V any]() Tree[K, V] {
+	return NewMultiWithOrder[K, V](defaultK)
+}
+
+// NewMultiWithOrder returns an empty multi tree of order k.
+func NewMultiWithOrder[K constraints.Ordered, V any](k int) Tree[K, V] {
+	return NewMultiFuncWithOrder[K, V](k, cmp.Compare[K])
+}
+
+// NewMultiFunc returns an empty multi tree that orders its keys using compare.
+func NewMultiFunc[K any, V any](compare func(a, b K) int) Tree[K, V] {
+	return NewMultiFuncWithOrder[K, V](defaultK, compare)
+}
+
+// NewMultiFuncWithOrder returns an empty multi tree of order k that orders its
+// keys using compare.
+func NewMultiFuncWithOrder[K any, V any](k int, compare func(a, b K) int) Tree[K, V] {
+	t := NewFuncWithOrder[K, V](k, compare)
+	t.multi = true
+	return t
+}
+
+// InsertDup adds an entry for key after any existing entries with key. It
+// panics if the tree was not made by one of the NewMulti functions.
+func (t *Tree[K, V]) InsertDup(key K, value V) {
+	if !t.multi {
+		panic("btree: InsertDup called on a tree that is not a multi tree")
+	}
+
+	if t.root == nil {
+		t.ready()
+		t.root = newPage[K, V](t.order, t.owner)
+	}
+
+	var buf [maxPathLen]pathFrame[K, V]
+	path, _ := t.mutablePath(key, t.searchFunc(true), buf[:0])
+	t.insertAt(path, entry[K, V]{key: key, value: value})
+}
+
+// FindAll returns an iterator over the values of every entry with key, in the
+// order they were added.
+func (t *Tree[K, V]) FindAll(key K) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		c := t.Cursor()
+		for ok := c.Seek(key); ok && t.compare(c.Key(), key) == 0; ok = c.Next() {
+			if !yield(c.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// DeleteOne removes the first entry with key whose value satisfies pred, and
+// returns its value.
+func (t *Tree[K, V]) DeleteOne(key K, pred func(V) bool) (V, bool) {
+	var zeroValue V
+
+	first := t.Rank(key)
+	c := t.Cursor()
+	for ok, i := c.Seek(key), first; ok && t.compare(c.Key(), key) == 0; ok, i = c.Next(), i+1 {
+		if pred(c.Value()) {
+			var buf [maxPathLen]pathFrame[K, V]
+			return t.deleteAt(t.mutablePathAt(i, buf[:0])), true
+		}
+	}
+
+	return zeroValue, false
+}
+
+// Count returns the number of entries with key.
+func (t *Tree[K, V]) Count(key K) int {
+	return t.CountRange(Inclusive(key), Inclusive(key))
+}
+
+// indexOf returns the index in key order of the first entry with key.
+func (t *Tree[K, V]) indexOf(key K) (int, bool) {
+	i := t.Rank(key)
+	if k, _, ok := t.Select(i); ok && t.compare(k, key) == 0 {
+		return i, true
+	}
+	return i, false
+}
+
+// searchFunc returns the comparison to search pages for a key with. In a multi
+// tree, entries with keys equal to the one sought are ordered before it when
+// after is set, and after it otherwise, so that a search lands after or before
+// all of them rather than on an arbitrary one and never reports a match.
+func (t *Tree[K, V]) searchFunc(after bool) func(a, b K) int {
+	if !t.multi {
+		return t.compare
+	}
+
+	tie := 1
+	if after {
+		tie = -1
+	}
+
+	return func(a, b K) int {
+		if c := t.compare(a, b); c != 0 {
+			return c
+		}
+		return tie
+	}
+}
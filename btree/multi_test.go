@@ -0,0 +1,258 @@
+package btree_test
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiTree(t *testing.T) {
+	t.Run("InsertDup panics on a tree that is not a multi tree", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+
+		// When/Then
+		require.Panics(t, func() { bt.InsertDup(1, 1) })
+	})
+
+	t.Run("it keeps entries with equal keys in the order they were added", func(t *testing.T) {
+		propFunc := func(keys []uint8) bool {
+			// Given
+			bt, oracle := newMultiTreeFrom(keys)
+
+			// When
+			collected := collectPairs(&bt)
+
+			// Then
+			return assert.NotPanics(t, bt.AssertInvariantsHold) &&
+				assert.Equal(t, len(oracle), bt.Len()) &&
+				assert.Equal(t, oracle, collected)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("FindAll and Count see every entry with the key", func(t *testing.T) {
+		propFunc := func(keys []uint8, key uint8) bool {
+			// Given
+			bt, oracle := newMultiTreeFrom(keys)
+			key %= multiKeys
+
+			// When
+			found := slices.Collect(bt.FindAll(key))
+			count := bt.Count(key)
+
+			// Then
+			expected := valuesOf(oracle, key)
+			return assert.Equal(t, expected, found) && assert.Equal(t, len(expected), count)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("Find, Insert and Delete act on the first entry with the key", func(t *testing.T) {
+		// Given
+		bt := btree.NewMulti[int, string]()
+		bt.InsertDup(1, "a")
+		bt.InsertDup(2, "b")
+		bt.InsertDup(1, "c")
+
+		// When
+		value, found := bt.Find(1)
+
+		// Then
+		require.True(t, found)
+		require.Equal(t, "a", value)
+
+		// When
+		bt.Insert(1, "d")
+
+		// Then
+		require.Equal(t, []string{"d", "c"}, slices.Collect(bt.FindAll(1)))
+
+		// When
+		value, found = bt.Delete(1)
+
+		// Then
+		require.True(t, found)
+		require.Equal(t, "d", value)
+		require.Equal(t, []string{"c"}, slices.Collect(bt.FindAll(1)))
+		require.Equal(t, 2, bt.Len())
+	})
+
+	t.Run("DeleteOne removes the first matching entry with the key", func(t *testing.T) {
+		propFunc := func(keys []uint8, deletions []uint8) bool {
+			// Given
+			bt, oracle := newMultiTreeFrom(keys)
+
+			for _, d := range deletions {
+				key, parity := d%multiKeys, int(d/multiKeys)%2
+				matches := func(value int) bool { return value%2 == parity }
+
+				// When
+				value, found := bt.DeleteOne(key, matches)
+
+				// Then
+				i := slices.IndexFunc(oracle, func(p multiPair) bool { return p.key == key && matches(p.value) })
+				if !assert.Equal(t, i >= 0, found, "key=%d", key) {
+					return false
+				}
+				if i >= 0 {
+					if !assert.Equal(t, oracle[i].value, value) {
+						return false
+					}
+					oracle = slices.Delete(oracle, i, i+1)
+				}
+				if !assert.NotPanics(t, bt.AssertInvariantsHold) {
+					return false
+				}
+			}
+
+			return assert.Equal(t, oracle, collectPairs(&bt))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("ranges include every entry with a bound's key", func(t *testing.T) {
+		propFunc := func(keys []uint8, from, to, fromKind, toKind uint8) bool {
+			// Given
+			bt, oracle := newMultiTreeFrom(keys)
+			lower, inLower := newBound(from%multiKeys, fromKind, func(a, b uint8) bool { return a >= b })
+			upper, inUpper := newBound(to%multiKeys, toKind, func(a, b uint8) bool { return a <= b })
+
+			// When
+			ascending := collectValues(bt.Ascend(lower, upper))
+			descending := collectValues(bt.Descend(upper, lower))
+			count := bt.CountRange(lower, upper)
+
+			// Then
+			expected := make([]int, 0)
+			for _, p := range oracle {
+				if inLower(p.key) && inUpper(p.key) {
+					expected = append(expected, p.value)
+				}
+			}
+			reversed := slices.Clone(expected)
+			slices.Reverse(reversed)
+
+			return assert.Equal(t, expected, ascending) &&
+				assert.Equal(t, reversed, descending) &&
+				assert.Equal(t, len(expected), count)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("Floor and Ceiling find the last and first entries with a key", func(t *testing.T) {
+		propFunc := func(keys []uint8, key uint8) bool {
+			// Given
+			bt, oracle := newMultiTreeFrom(keys)
+			key %= multiKeys
+
+			// When
+			_, floor, floorFound := bt.Floor(key)
+			_, ceiling, ceilingFound := bt.Ceiling(key)
+			rank := bt.Rank(key)
+
+			// Then
+			// i is the index of the first entry with a key of at least key, and j of
+			// the first with a greater key.
+			i := countWhile(oracle, func(p multiPair) bool { return p.key < key })
+			j := countWhile(oracle, func(p multiPair) bool { return p.key <= key })
+
+			return assert.Equal(t, i < len(oracle), ceilingFound) &&
+				(i == len(oracle) || assert.Equal(t, oracle[i].value, ceiling)) &&
+				assert.Equal(t, j > 0, floorFound) &&
+				(j == 0 || assert.Equal(t, oracle[j-1].value, floor)) &&
+				assert.Equal(t, i, rank)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("Load accepts equal keys", func(t *testing.T) {
+		// Given
+		bt := btree.NewMulti[int, int]()
+
+		// When
+		err := bt.Load(func(yield func(int, int) bool) {
+			for i, key := range []int{1, 1, 2, 2, 2, 3} {
+				if !yield(key, i) {
+					return
+				}
+			}
+		})
+
+		// Then
+		require.NoError(t, err)
+		require.NotPanics(t, bt.AssertInvariantsHold)
+		require.Equal(t, []int{2, 3, 4}, slices.Collect(bt.FindAll(2)))
+	})
+}
+
+// multiKeys is the number of distinct keys used by the multi tree tests, which
+// is small so that most keys have several entries.
+const multiKeys = 8
+
+type multiPair struct {
+	key   uint8
+	value int
+}
+
+// newMultiTreeFrom adds an entry for each of keys, reduced to one of multiKeys
+// keys, with its position in keys as its value. It also returns the entries in
+// the order the tree should hold them.
+func newMultiTreeFrom(keys []uint8) (btree.Tree[uint8, int], []multiPair) {
+	bt := btree.NewMulti[uint8, int]()
+	oracle := make([]multiPair, 0, len(keys))
+
+	for i, key := range keys {
+		key %= multiKeys
+		bt.InsertDup(key, i)
+		oracle = append(oracle, multiPair{key, i})
+	}
+
+	slices.SortStableFunc(oracle, func(a, b multiPair) int { return cmp.Compare(a.key, b.key) })
+	return bt, oracle
+}
+
+func valuesOf(pairs []multiPair, key uint8) []int {
+	var found []int
+	for _, p := range pairs {
+		if p.key == key {
+			found = append(found, p.value)
+		}
+	}
+	return found
+}
+
+func countWhile(pairs []multiPair, pred func(multiPair) bool) int {
+	n := 0
+	for n < len(pairs) && pred(pairs[n]) {
+		n += 1
+	}
+	return n
+}
+
+func collectValues[K any, V any](seq iter.Seq2[K, V]) []V {
+	collected := make([]V, 0)
+	for _, value := range seq {
+		collected = append(collected, value)
+	}
+	return collected
+}
+
+func collectPairs(bt *btree.Tree[uint8, int]) []multiPair {
+	collected := make([]multiPair, 0)
+	for key, value := range bt.All() {
+		collected = append(collected, multiPair{key, value})
+	}
+	return collected
+}
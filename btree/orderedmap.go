@@ -0,0 +1,114 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// OrderedMap is a map that keeps its keys in order, as Tree, BPlusTree and
+// SliceMap do. The package btreetest has tests that any implementation should
+// pass.
+type OrderedMap[K any, V any] interface {
+	// Insert sets the value of key, adding an entry for it if there is none.
+	Insert(key K, value V)
+
+	// Find returns the value of key, and whether there is an entry for it.
+	Find(key K) (V, bool)
+
+	// Delete removes the entry with key and returns its value, and whether
+	// there was an entry for it.
+	Delete(key K) (V, bool)
+
+	// Len returns the number of entries.
+	Len() int
+
+	// Range returns an iterator over the entries with keys in the half-open
+	// range [from, to) in ascending key order.
+	Range(from, to K) iter.Seq2[K, V]
+}
+
+var (
+	_ OrderedMap[int, int] = (*Tree[int, int])(nil)
+	_ OrderedMap[int, int] = (*BPlusTree[int, int])(nil)
+	_ OrderedMap[int, int] = (*SliceMap[int, int])(nil)
+)
+
+// SliceMap is an OrderedMap that keeps its entries in a sorted slice. Inserting
+// and deleting take time linear in the number of entries, so it is meant as a
+// reference to check other implementations against rather than for real use.
+//
+// The zero value is not ready to use. Create one with NewSliceMap or
+// NewSliceMapFunc.
+type SliceMap[K any, V any] struct {
+	entries []entry[K, V]
+	compare func(a, b K) int
+}
+
+func NewSliceMap[K constraints.Ordered, V any]() SliceMap[K, V] {
+	return NewSliceMapFunc[K, V](cmp.Compare[K])
+}
+
+// NewSliceMapFunc returns an empty slice map that orders its keys using
+// compare. See NewFunc.
+func NewSliceMapFunc[K any, V any](compare func(a, b K) int) SliceMap[K, V] {
+	return SliceMap[K, V]{compare: compare}
+}
+
+func (m *SliceMap[K, V]) Insert(key K, value V) {
+	i, found := searchEntries(m.entries, key, m.compare)
+	if found {
+		m.entries[i].value = value
+		return
+	}
+	m.entries = slices.Insert(m.entries, i, entry[K, V]{key: key, value: value})
+}
+
+func (m *SliceMap[K, V]) Find(key K) (V, bool) {
+	i, found := searchEntries(m.entries, key, m.compare)
+	if !found {
+		var zeroValue V
+		return zeroValue, false
+	}
+	return m.entries[i].value, true
+}
+
+func (m *SliceMap[K, V]) Delete(key K) (V, bool) {
+	i, found := searchEntries(m.entries, key, m.compare)
+	if !found {
+		var zeroValue V
+		return zeroValue, false
+	}
+
+	value := m.entries[i].value
+	m.entries = slices.Delete(m.entries, i, i+1)
+	return value, true
+}
+
+func (m *SliceMap[K, V]) Len() int {
+	return len(m.entries)
+}
+
+// All returns an iterator over every entry in ascending key order.
+func (m *SliceMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, e := range m.entries {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (m *SliceMap[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		i, _ := searchEntries(m.entries, from, m.compare)
+		for ; i < len(m.entries) && m.compare(m.entries[i].key, to) < 0; i += 1 {
+			if !yield(m.entries[i].key, m.entries[i].value) {
+				return
+			}
+		}
+	}
+}
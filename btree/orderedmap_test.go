@@ -0,0 +1,59 @@
+package btree_test
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/munckymagik/gokb/btree/btreetest"
+)
+
+func TestOrderedMapConformance(t *testing.T) {
+	for _, k := range []int{1, 2, 8} {
+		t.Run(fmt.Sprintf("Tree/k=%d", k), func(t *testing.T) {
+			btreetest.TestOrderedMap(t, func() btree.OrderedMap[int8, int] {
+				bt := btree.NewWithOrder[int8, int](k)
+				return &bt
+			}, cmp.Compare[int8], randomInt8, (*rand.Rand).Int)
+		})
+
+		t.Run(fmt.Sprintf("BPlusTree/k=%d", k), func(t *testing.T) {
+			btreetest.TestOrderedMap(t, func() btree.OrderedMap[int8, int] {
+				bpt := btree.NewBPlusWithOrder[int8, int](k)
+				return &bpt
+			}, cmp.Compare[int8], randomInt8, (*rand.Rand).Int)
+		})
+	}
+
+	t.Run("SliceMap", func(t *testing.T) {
+		btreetest.TestOrderedMap(t, func() btree.OrderedMap[int8, int] {
+			m := btree.NewSliceMap[int8, int]()
+			return &m
+		}, cmp.Compare[int8], randomInt8, (*rand.Rand).Int)
+	})
+
+	t.Run("Tree with string keys in reverse order", func(t *testing.T) {
+		reverse := func(a, b string) int { return strings.Compare(b, a) }
+		btreetest.TestOrderedMap(t, func() btree.OrderedMap[string, []byte] {
+			bt := btree.NewFunc[string, []byte](reverse)
+			return &bt
+		}, reverse, randomString, func(rng *rand.Rand) []byte { return []byte(randomString(rng)) })
+	})
+}
+
+func randomInt8(rng *rand.Rand) int8 {
+	return int8(rng.Intn(256) - 128)
+}
+
+// randomString returns a short string of a few letters, so that the same
+// string comes up often.
+func randomString(rng *rand.Rand) string {
+	var b strings.Builder
+	for range rng.Intn(4) {
+		b.WriteByte("abc"[rng.Intn(3)])
+	}
+	return b.String()
+}
@@ -1,45 +1,86 @@
 package btree
 
-import "golang.org/x/exp/constraints"
-
 type entry[K any, V any] struct {
 	key   K
 	value V
 }
 
-type page[K constraints.Ordered, V any] struct {
-	parent   *page[K, V]
+// owner identifies the tree that may modify a page. Pages are shared between a
+// tree and its clones, and a tree copies any page it does not own before it
+// modifies it.
+type owner struct {
+	_ byte // so that every owner has a distinct address
+}
+
+type page[K any, V any] struct {
+	owner    *owner
 	entries  items[entry[K, V]]
 	children items[*page[K, V]]
+	count    int // entries in the subtree rooted at this page
 }
 
-func newPage[K constraints.Ordered, V any](parent *page[K, V]) *page[K, V] {
-	return &page[K, V]{parent: parent}
+// pathFrame is a step on the path from the root to a page. The index is the
+// child descended into from the page, or the entry of interest in the page at
+// the end of the path.
+type pathFrame[K any, V any] struct {
+	page  *page[K, V]
+	index int
 }
 
-func (p *page[K, V]) isRoot() bool {
-	return p.parent == nil
+// newPage allocates the entries of the page up front with room for one more
+// than the maximum, so that a page can overflow before it is split without its
+// entries ever being reallocated.
+func newPage[K any, V any](o order, owner *owner) *page[K, V] {
+	return &page[K, V]{
+		owner:   owner,
+		entries: make(items[entry[K, V]], 0, o.maxEntries+1),
+	}
 }
 
-func (p *page[K, V]) add(key K, value V) {
-	newEntry := entry[K, V]{key: key, value: value}
-	p.entries.add(newEntry)
-	p.sort()
+// clone returns a copy of the page owned by owner, which shares its children
+// with the original.
+func (p *page[K, V]) clone(o order, owner *owner) *page[K, V] {
+	c := newPage[K, V](o, owner)
+	c.entries = append(c.entries, p.entries...)
+	c.count = p.count
+
+	if !p.isLeaf() {
+		c.addChildren(o, p.children...)
+	}
+
+	return c
 }
 
-func (p *page[K, V]) setChildren(incoming ...*page[K, V]) {
-	p.children = incoming
-	for _, child := range p.children {
-		child.parent = p
+// mutableChild returns the child at index i, first replacing it with a copy if
+// it is not owned by the same tree as p.
+func (p *page[K, V]) mutableChild(o order, i int) *page[K, V] {
+	child := p.children[i]
+	if child.owner != p.owner {
+		child = child.clone(o, p.owner)
+		p.children[i] = child
 	}
+	return child
+}
+
+func (p *page[K, V]) isLeaf() bool {
+	return len(p.children) == 0
 }
 
-func (p *page[K, V]) sort() {
-	p.entries.sort(func(e1, e2 entry[K, V]) bool { return e1.key < e2.key })
+func (p *page[K, V]) addChildren(o order, incoming ...*page[K, V]) {
+	if p.children == nil {
+		p.children = make(items[*page[K, V]], 0, o.maxChildren+1)
+	}
+
+	p.children = append(p.children, incoming...)
+}
 
-	p.children.sort(func(c1, c2 *page[K, V]) bool {
-		return c1.entries.last().key < c2.entries.first().key
-	})
+// recount recalculates the number of entries in the subtree from the entries
+// of the page and the counts of its children.
+func (p *page[K, V]) recount() {
+	p.count = len(p.entries)
+	for _, child := range p.children {
+		p.count += child.count
+	}
 }
 
 func (p *page[K, V]) traverseSubtree(f func(K, V)) {
@@ -56,21 +97,41 @@ func (p *page[K, V]) traverseSubtree(f func(K, V)) {
 	}
 }
 
-func (p *page[K, V]) find(key K) (*entry[K, V], *page[K, V]) {
-	i := 0
-	for ; i < len(p.entries); i += 1 {
-		if key == p.entries[i].key {
-			return &p.entries[i], p
-		}
+// search binary searches the entries of the page for key. It returns the index
+// of the first entry with a key greater than or equal to key, which is also the
+// index of the child to descend into when key is not found, and whether that
+// entry's key is equal to key.
+func (p *page[K, V]) search(key K, compare func(a, b K) int) (int, bool) {
+	return searchEntries(p.entries, key, compare)
+}
 
-		if key < p.entries[i].key {
-			break
+func searchEntries[K any, V any](entries []entry[K, V], key K, compare func(a, b K) int) (int, bool) {
+	lo, hi := 0, len(entries)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch c := compare(entries[mid].key, key); {
+		case c < 0:
+			lo = mid + 1
+		case c > 0:
+			hi = mid
+		default:
+			return mid, true
 		}
 	}
 
-	if i < len(p.children) {
-		return p.children[i].find(key)
-	}
+	return lo, false
+}
 
-	return nil, p
+// find descends the subtree looking for key. It returns the page and index of
+// the entry when key is found, otherwise the leaf page and index at which key
+// should be inserted.
+func (p *page[K, V]) find(key K, compare func(a, b K) int) (*page[K, V], int, bool) {
+	for {
+		i, found := p.search(key, compare)
+		if found || p.isLeaf() {
+			return p, i, found
+		}
+
+		p = p.children[i]
+	}
 }
@@ -1,6 +1,7 @@
 package btree
 
 import (
+	"cmp"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,27 +10,74 @@ import (
 func TestPageFind(t *testing.T) {
 	t.Run("when the page is empty", func(t *testing.T) {
 		// Given
-		p := newPage[int, emptyValue](nil)
+		p := newPage[int, emptyValue](newOrder(defaultK), nil)
 
 		//  When
-		entry, leaf := p.find(1)
+		leaf, i, found := p.find(1, cmp.Compare[int])
 
 		// Then
-		require.Nil(t, entry)
+		require.False(t, found)
 		require.Equal(t, p, leaf)
+		require.Zero(t, i)
 	})
 	t.Run("when the page has entries", func(t *testing.T) {
 		// Given
-		p := newPage[int, emptyValue](nil)
+		p := newPageWithKeys(1, 3)
 
 		//  When
-		entry, leaf := p.find(1)
+		leaf, i, found := p.find(3, cmp.Compare[int])
 
 		// Then
-		require.Nil(t, entry)
+		require.True(t, found)
 		require.Equal(t, p, leaf)
+		require.Equal(t, 1, i)
 	})
-	t.Run("when the page has children", func(t *testing.T) {})
+	t.Run("when the page has children", func(t *testing.T) {
+		// Given
+		p := newPageWithKeys(3)
+		left, right := newPageWithKeys(1, 2), newPageWithKeys(4, 6)
+		p.addChildren(newOrder(defaultK), left, right)
+
+		//  When
+		leaf, i, found := p.find(5, cmp.Compare[int])
+
+		// Then
+		require.False(t, found)
+		require.Equal(t, right, leaf)
+		require.Equal(t, 1, i)
+	})
+}
+
+func TestPageSearch(t *testing.T) {
+	p := newPageWithKeys(10, 20, 30, 40)
+
+	for _, tc := range []struct {
+		key   int
+		index int
+		found bool
+	}{
+		{5, 0, false},
+		{10, 0, true},
+		{15, 1, false},
+		{30, 2, true},
+		{40, 3, true},
+		{45, 4, false},
+	} {
+		// When
+		i, found := p.search(tc.key, cmp.Compare[int])
+
+		// Then
+		require.Equal(t, tc.index, i, "key=%d", tc.key)
+		require.Equal(t, tc.found, found, "key=%d", tc.key)
+	}
+}
+
+func newPageWithKeys(keys ...int) *page[int, emptyValue] {
+	p := newPage[int, emptyValue](newOrder(len(keys)), nil)
+	for _, key := range keys {
+		p.entries.add(entry[int, emptyValue]{key: key})
+	}
+	return p
 }
 
 type emptyValue = struct{}
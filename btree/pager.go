@@ -0,0 +1,210 @@
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrCorrupt       = errors.New("btree: file is corrupt")
+	ErrEntryTooLarge = errors.New("btree: entry is too large for a page")
+)
+
+// blockID identifies a fixed-size block within a file. Block 0 holds the file
+// header, so 0 also stands for no block at all.
+type blockID uint32
+
+const (
+	fileMagic   = "GOKB"
+	fileVersion = 1
+
+	// magic, version, blockSize, k, root, numEntries, freeHead, numBlocks
+	fileHeaderSize = 4 + 2 + 4 + 4 + 4 + 8 + 4 + 4
+)
+
+// The first byte of every block other than the header says what it holds.
+const (
+	freeBlock byte = iota + 1
+	leafBlock
+	internalBlock
+)
+
+type fileHeader struct {
+	blockSize  uint32
+	k          uint32
+	root       blockID
+	numEntries uint64
+	freeHead   blockID
+	numBlocks  uint32
+}
+
+// pager reads and writes the fixed-size blocks of a file, and allocates them
+// from a free list of released blocks before growing the file.
+type pager struct {
+	file   *os.File
+	header fileHeader
+
+	// released holds the blocks freed since the last sync. They only join the
+	// free list at the next sync, so that the pages of the tree as it was at
+	// the last sync are not overwritten before the header stops pointing to
+	// them.
+	released []blockID
+}
+
+// openPager opens the file at path, initialising it with the given block size
+// and order if it is empty. An existing file keeps the block size and order it
+// was created with.
+func openPager(path string, blockSize int, k int) (*pager, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	pg := &pager{file: file}
+
+	if info.Size() == 0 {
+		pg.header = fileHeader{blockSize: uint32(blockSize), k: uint32(k), numBlocks: 1}
+		err = pg.writeHeader()
+	} else {
+		err = pg.readHeader()
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return pg, nil
+}
+
+func (pg *pager) blockSize() int {
+	return int(pg.header.blockSize)
+}
+
+func (pg *pager) offset(id blockID) int64 {
+	return int64(id) * int64(pg.header.blockSize)
+}
+
+func (pg *pager) readHeader() error {
+	buf := make([]byte, fileHeaderSize)
+	if _, err := pg.file.ReadAt(buf, 0); err != nil {
+		return fmt.Errorf("%w: reading header: %w", ErrCorrupt, err)
+	}
+
+	if string(buf[:4]) != fileMagic {
+		return fmt.Errorf("%w: bad magic %q", ErrCorrupt, buf[:4])
+	}
+
+	if version := binary.BigEndian.Uint16(buf[4:]); version != fileVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrCorrupt, version)
+	}
+
+	pg.header = fileHeader{
+		blockSize:  binary.BigEndian.Uint32(buf[6:]),
+		k:          binary.BigEndian.Uint32(buf[10:]),
+		root:       blockID(binary.BigEndian.Uint32(buf[14:])),
+		numEntries: binary.BigEndian.Uint64(buf[18:]),
+		freeHead:   blockID(binary.BigEndian.Uint32(buf[26:])),
+		numBlocks:  binary.BigEndian.Uint32(buf[30:]),
+	}
+
+	if pg.header.blockSize < fileHeaderSize || pg.header.k < 1 {
+		return fmt.Errorf("%w: invalid block size %d or order %d", ErrCorrupt, pg.header.blockSize, pg.header.k)
+	}
+
+	return nil
+}
+
+func (pg *pager) writeHeader() error {
+	block := make([]byte, 0, pg.blockSize())
+	block = append(block, fileMagic...)
+	block = binary.BigEndian.AppendUint16(block, fileVersion)
+	block = binary.BigEndian.AppendUint32(block, pg.header.blockSize)
+	block = binary.BigEndian.AppendUint32(block, pg.header.k)
+	block = binary.BigEndian.AppendUint32(block, uint32(pg.header.root))
+	block = binary.BigEndian.AppendUint64(block, pg.header.numEntries)
+	block = binary.BigEndian.AppendUint32(block, uint32(pg.header.freeHead))
+	block = binary.BigEndian.AppendUint32(block, pg.header.numBlocks)
+
+	return pg.write(0, block[:cap(block)])
+}
+
+func (pg *pager) read(id blockID) ([]byte, error) {
+	if id == 0 || uint32(id) >= pg.header.numBlocks {
+		return nil, fmt.Errorf("%w: block %d out of range", ErrCorrupt, id)
+	}
+
+	block := make([]byte, pg.blockSize())
+	if _, err := pg.file.ReadAt(block, pg.offset(id)); err != nil {
+		return nil, fmt.Errorf("%w: reading block %d: %w", ErrCorrupt, id, err)
+	}
+
+	return block, nil
+}
+
+func (pg *pager) write(id blockID, block []byte) error {
+	_, err := pg.file.WriteAt(block, pg.offset(id))
+	return err
+}
+
+// allocate returns a block to write a new page to, reusing a released block if
+// there is one.
+func (pg *pager) allocate() (blockID, error) {
+	if pg.header.freeHead == 0 {
+		id := blockID(pg.header.numBlocks)
+		pg.header.numBlocks += 1
+		return id, nil
+	}
+
+	id := pg.header.freeHead
+	block, err := pg.read(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if block[0] != freeBlock {
+		return 0, fmt.Errorf("%w: block %d on the free list is in use", ErrCorrupt, id)
+	}
+
+	pg.header.freeHead = blockID(binary.BigEndian.Uint32(block[1:]))
+	return id, nil
+}
+
+// free releases a block, to be pushed on to the free list at the next sync.
+func (pg *pager) free(id blockID) {
+	pg.released = append(pg.released, id)
+}
+
+// sync pushes the blocks released since the last sync on to the free list,
+// writes the header and flushes the file to stable storage.
+func (pg *pager) sync() error {
+	block := make([]byte, pg.blockSize())
+	block[0] = freeBlock
+	for len(pg.released) > 0 {
+		id := pg.released[len(pg.released)-1]
+		binary.BigEndian.PutUint32(block[1:], uint32(pg.header.freeHead))
+		if err := pg.write(id, block); err != nil {
+			return err
+		}
+
+		pg.header.freeHead = id
+		pg.released = pg.released[:len(pg.released)-1]
+	}
+
+	if err := pg.writeHeader(); err != nil {
+		return err
+	}
+	return pg.file.Sync()
+}
+
+func (pg *pager) close() error {
+	return pg.file.Close()
+}
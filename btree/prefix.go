@@ -0,0 +1,207 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+	"strings"
+)
+
+// minSharedPrefix is the shortest prefix worth sharing between keys, which is
+// the size of the pointer each key needs to refer to it.
+const minSharedPrefix = 8
+
+// PrefixScan returns an iterator over the entries with keys that start with
+// prefix in ascending key order. The tree must order its keys bytewise, as
+// New does.
+func PrefixScan[V any](t *Tree[string, V], prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		c := t.Cursor()
+		for ok := c.Seek(prefix); ok && strings.HasPrefix(c.Key(), prefix); ok = c.Next() {
+			if !yield(c.Key(), c.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// StringTree is a tree of string keys that stores the prefix shared by the keys
+// in a page only once. It suits keys such as URLs and file paths
+// that have long prefixes in common, in exchange for building a new string for
+// each key it returns.
+//
+// Every StringTree orders its keys in the same way, so the zero value is an
+// empty tree of the default order ready to use.
+type StringTree[V any] struct {
+	tree Tree[prefixedKey, V]
+}
+
+// prefixedKey is a key split into a prefix, which may be shared with other
+// keys, and the suffix that follows it.
+type prefixedKey struct {
+	prefix *string
+	suffix string
+}
+
+func NewStringTree[V any]() StringTree[V] {
+	return NewStringTreeWithOrder[V](defaultK)
+}
+
+// NewStringTreeWithOrder returns an empty string tree of order k. See
+// NewWithOrder.
+func NewStringTreeWithOrder[V any](k int) StringTree[V] {
+	return StringTree[V]{tree: NewFuncWithOrder[prefixedKey, V](k, comparePrefixed)}
+}
+
+func (t *StringTree[V]) Len() int {
+	return t.tree.Len()
+}
+
+// Insert sets the value of key, adding an entry for it if there is none. A new
+// key shares the prefix common to all the keys in its page when it is at least
+// minSharedPrefix bytes long.
+func (t *StringTree[V]) Insert(key string, value V) {
+	tree := &t.tree
+	if tree.root == nil {
+		if tree.compare == nil {
+			tree.compare = comparePrefixed
+		}
+		tree.ready()
+		tree.root = newPage[prefixedKey, V](tree.order, tree.owner)
+	}
+
+	var buf [maxPathLen]pathFrame[prefixedKey, V]
+	path, found := tree.mutablePath(prefixedKey{suffix: key}, tree.compare, buf[:0])
+
+	top := path[len(path)-1]
+	if found {
+		top.page.entries[top.index].value = value
+		return
+	}
+
+	tree.insertAt(path, entry[prefixedKey, V]{key: compress(top.page.entries, key), value: value})
+}
+
+func (t *StringTree[V]) Find(key string) (V, bool) {
+	return t.tree.Find(prefixedKey{suffix: key})
+}
+
+func (t *StringTree[V]) Delete(key string) (V, bool) {
+	return t.tree.Delete(prefixedKey{suffix: key})
+}
+
+// All returns an iterator over every entry in ascending key order.
+func (t *StringTree[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		for key, value := range t.tree.All() {
+			if !yield(key.String(), value) {
+				return
+			}
+		}
+	}
+}
+
+// PrefixScan returns an iterator over the entries with keys that start with
+// prefix in ascending key order.
+func (t *StringTree[V]) PrefixScan(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		c := t.tree.Cursor()
+		for ok := c.Seek(prefixedKey{suffix: prefix}); ok && c.Key().hasPrefix(prefix); ok = c.Next() {
+			if !yield(c.Key().String(), c.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// compress returns key split after the prefix that it has in common with every
+// key in a page, which the keys share. When the page does not already share
+// that prefix, its keys are split again to share it. The suffix is copied so
+// that none of the caller's string is kept.
+func compress[V any](entries []entry[prefixedKey, V], key string) prefixedKey {
+	if len(entries) == 0 {
+		return prefixedKey{suffix: key}
+	}
+
+	// The keys are in order, so key has as much in common with all of them as
+	// it does with the first and last.
+	first, last := entries[0].key, entries[len(entries)-1].key
+	n := min(first.commonPrefixLen(key), last.commonPrefixLen(key))
+	if n < minSharedPrefix {
+		return prefixedKey{suffix: key}
+	}
+
+	shared := first.prefix
+	if shared == nil || len(*shared) != n {
+		prefix := strings.Clone(key[:n])
+		shared = &prefix
+		for i := range entries {
+			entries[i].key = entries[i].key.withPrefix(shared)
+		}
+	}
+
+	return prefixedKey{prefix: shared, suffix: strings.Clone(key[n:])}
+}
+
+func (k prefixedKey) head() string {
+	if k.prefix == nil {
+		return ""
+	}
+	return *k.prefix
+}
+
+func (k prefixedKey) String() string {
+	return k.head() + k.suffix
+}
+
+// withPrefix returns the key split at the end of prefix instead, which it must
+// start with.
+func (k prefixedKey) withPrefix(prefix *string) prefixedKey {
+	return prefixedKey{prefix: prefix, suffix: strings.Clone(k.String()[len(*prefix):])}
+}
+
+// commonPrefixLen returns the number of bytes at the start of k and s that are
+// the same.
+func (k prefixedKey) commonPrefixLen(s string) int {
+	n := 0
+	for _, part := range [2]string{k.head(), k.suffix} {
+		for i := 0; i < len(part); i += 1 {
+			if n == len(s) || s[n] != part[i] {
+				return n
+			}
+			n += 1
+		}
+	}
+	return n
+}
+
+func (k prefixedKey) hasPrefix(prefix string) bool {
+	return k.commonPrefixLen(prefix) == len(prefix)
+}
+
+// comparePrefixed compares keys bytewise as though their prefixes and suffixes
+// were joined, without joining them.
+func comparePrefixed(a, b prefixedKey) int {
+	if a.prefix == b.prefix {
+		return strings.Compare(a.suffix, b.suffix)
+	}
+
+	a1, a2 := a.head(), a.suffix
+	b1, b2 := b.head(), b.suffix
+	for {
+		if len(a1) == 0 {
+			a1, a2 = a2, ""
+		}
+		if len(b1) == 0 {
+			b1, b2 = b2, ""
+		}
+		if len(a1) == 0 || len(b1) == 0 {
+			return cmp.Compare(len(a1), len(b1))
+		}
+
+		n := min(len(a1), len(b1))
+		if c := strings.Compare(a1[:n], b1[:n]); c != 0 {
+			return c
+		}
+		a1, b1 = a1[n:], b1[n:]
+	}
+}
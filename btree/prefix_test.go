@@ -0,0 +1,199 @@
+package btree_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scanPrefixes = []string{
+	"",
+	"https://",
+	"https://example.com/a",
+	"https://example.com/a/b/",
+	"https://example.com/c/a/1",
+	"https://example.com/d",
+	"~",
+}
+
+func TestPrefixScan(t *testing.T) {
+	t.Run("it yields the keys that start with the prefix in order", func(t *testing.T) {
+		propFunc := func(ids []uint16) bool {
+			// Given
+			keys := urlKeys(ids)
+			bt := newBTreeFrom(keys)
+			sortedKeys := cloneSortAndCompact(keys)
+
+			for _, prefix := range scanPrefixes {
+				// When
+				scanned := collectKeys(btree.PrefixScan(&bt, prefix))
+
+				// Then
+				expected := filterKeys(sortedKeys, func(key string) bool { return strings.HasPrefix(key, prefix) })
+				if !assert.Equal(t, expected, scanned, "prefix=%q", prefix) {
+					return false
+				}
+			}
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, nil))
+	})
+
+	t.Run("it stops when the caller stops", func(t *testing.T) {
+		// Given
+		bt := newBTreeFrom([]string{"ab", "abc", "abd", "b"})
+
+		// When
+		scanned := make([]string, 0)
+		for key := range btree.PrefixScan(&bt, "ab") {
+			scanned = append(scanned, key)
+			if len(scanned) == 2 {
+				break
+			}
+		}
+
+		// Then
+		require.Equal(t, []string{"ab", "abc"}, scanned)
+	})
+}
+
+func TestStringTree(t *testing.T) {
+	t.Run("it behaves like a map", func(t *testing.T) {
+		propFunc := func(ids []uint16) bool {
+			// Given
+			st := btree.NewStringTreeWithOrder[int](2)
+			oracle := make(map[string]int)
+
+			for i, key := range urlKeys(ids) {
+				// When
+				if _, ok := oracle[key]; ok && i%3 == 0 {
+					value, found := st.Delete(key)
+					if !(assert.True(t, found) && assert.Equal(t, oracle[key], value)) {
+						return false
+					}
+					delete(oracle, key)
+				} else {
+					st.Insert(key, i)
+					oracle[key] = i
+				}
+			}
+
+			// Then
+			for key, value := range oracle {
+				found, ok := st.Find(key)
+				if !(assert.True(t, ok, key) && assert.Equal(t, value, found, key)) {
+					return false
+				}
+			}
+			_, found := st.Find("https://example.com/")
+			return assert.False(t, found) &&
+				assert.Equal(t, len(oracle), st.Len()) &&
+				assert.Equal(t, oracle, collectStringTreeEntries(&st))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it yields every key in order", func(t *testing.T) {
+		propFunc := func(ids []uint16) bool {
+			// Given
+			keys := urlKeys(ids)
+			st := newStringTreeFrom(keys)
+
+			// When
+			all := collectKeys(st.All())
+
+			// Then
+			return assert.Equal(t, cloneSortAndCompact(keys), all)
+		}
+
+		require.NoError(t, quick.Check(propFunc, nil))
+	})
+
+	t.Run("it yields the keys that start with the prefix in order", func(t *testing.T) {
+		propFunc := func(ids []uint16) bool {
+			// Given
+			keys := urlKeys(ids)
+			st := newStringTreeFrom(keys)
+			sortedKeys := cloneSortAndCompact(keys)
+
+			for _, prefix := range scanPrefixes {
+				// When
+				scanned := collectKeys(st.PrefixScan(prefix))
+
+				// Then
+				expected := filterKeys(sortedKeys, func(key string) bool { return strings.HasPrefix(key, prefix) })
+				if !assert.Equal(t, expected, scanned, "prefix=%q", prefix) {
+					return false
+				}
+			}
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, nil))
+	})
+
+	t.Run("the zero value is ready to use", func(t *testing.T) {
+		// Given
+		var st btree.StringTree[int]
+		keys := urlKeys([]uint16{1, 2, 3, 4, 5, 6, 7, 8})
+
+		// When
+		for i, key := range keys {
+			st.Insert(key, i)
+		}
+
+		// Then
+		require.Equal(t, len(keys), st.Len())
+		for i, key := range keys {
+			value, found := st.Find(key)
+			require.True(t, found, "key=%s", key)
+			require.Equal(t, i, value)
+		}
+		require.Equal(t, cloneSortAndCompact(keys), collectKeys(st.All()))
+	})
+
+	t.Run("it orders keys that are prefixes of one another", func(t *testing.T) {
+		// Given
+		keys := []string{"prefix/a/b", "prefix/a", "prefix/", "prefix/a/", "prefix/a/b/c", "prefix"}
+		st := newStringTreeFrom(keys)
+
+		// When
+		all := collectKeys(st.All())
+
+		// Then
+		require.Equal(t, cloneSortAndCompact(keys), all)
+	})
+}
+
+// urlKeys returns a URL for each id, arranged in a shallow hierarchy so that
+// the keys have long prefixes in common.
+func urlKeys(ids []uint16) []string {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf("https://example.com/%c/%c/%d", 'a'+id%3, 'a'+id/3%3, id%100)
+	}
+	return keys
+}
+
+func newStringTreeFrom(keys []string) btree.StringTree[int] {
+	st := btree.NewStringTreeWithOrder[int](2)
+	for i, key := range keys {
+		st.Insert(key, i)
+	}
+	return st
+}
+
+func collectStringTreeEntries(st *btree.StringTree[int]) map[string]int {
+	entries := make(map[string]int)
+	for key, value := range st.All() {
+		entries[key] = value
+	}
+	return entries
+}
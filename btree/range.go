@@ -0,0 +1,132 @@
+package btree
+
+import "iter"
+
+type boundKind int
+
+const (
+	unbounded boundKind = iota
+	inclusive
+	exclusive
+)
+
+// Bound is one end of a key range. The zero value is unbounded.
+type Bound[K any] struct {
+	key  K
+	kind boundKind
+}
+
+// Inclusive returns a bound that includes key itself in the range.
+func Inclusive[K any](key K) Bound[K] {
+	return Bound[K]{key: key, kind: inclusive}
+}
+
+// Exclusive returns a bound that excludes key itself from the range.
+func Exclusive[K any](key K) Bound[K] {
+	return Bound[K]{key: key, kind: exclusive}
+}
+
+// Unbounded returns a bound that does not limit the range at all.
+func Unbounded[K any]() Bound[K] {
+	return Bound[K]{}
+}
+
+// All returns an iterator over every entry in ascending key order.
+func (t *Tree[K, V]) All() iter.Seq2[K, V] {
+	return t.Ascend(Unbounded[K](), Unbounded[K]())
+}
+
+// Range returns an iterator over the entries with keys in the half-open range
+// [from, to) in ascending key order.
+func (t *Tree[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return t.Ascend(Inclusive(from), Exclusive(to))
+}
+
+// Ascend returns an iterator over the entries with keys from the lower bound
+// up to the upper bound in ascending key order.
+func (t *Tree[K, V]) Ascend(from, to Bound[K]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c := t.Cursor()
+		for ok := c.seekLower(from); ok && isBelow(t.compare, c.Key(), to); ok = c.Next() {
+			if !yield(c.Key(), c.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// Descend returns an iterator over the entries with keys from the upper bound
+// down to the lower bound in descending key order.
+func (t *Tree[K, V]) Descend(from, to Bound[K]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c := t.Cursor()
+		for ok := c.seekUpper(from); ok && isAbove(t.compare, c.Key(), to); ok = c.Prev() {
+			if !yield(c.Key(), c.Value()) {
+				return
+			}
+		}
+	}
+}
+
+// seekLower positions the cursor on the smallest key within the lower bound.
+func (c *Cursor[K, V]) seekLower(b Bound[K]) bool {
+	switch b.kind {
+	case inclusive:
+		return c.Seek(b.key)
+	case exclusive:
+		for ok := c.Seek(b.key); ok && c.tree.compare(c.Key(), b.key) == 0; {
+			ok = c.Next()
+		}
+		return c.Valid()
+	default:
+		return c.First()
+	}
+}
+
+// seekUpper positions the cursor on the largest key within the upper bound.
+func (c *Cursor[K, V]) seekUpper(b Bound[K]) bool {
+	switch b.kind {
+	case inclusive:
+		// Step back from the first key beyond the bound, so as to land on the
+		// last of the entries with the bound's key in a multi tree.
+		ok := c.Seek(b.key)
+		for ok && c.tree.compare(c.Key(), b.key) == 0 {
+			ok = c.Next()
+		}
+		if !ok {
+			return c.Last()
+		}
+		return c.Prev()
+	case exclusive:
+		if !c.Seek(b.key) {
+			return c.Last()
+		}
+		return c.Prev()
+	default:
+		return c.Last()
+	}
+}
+
+// isBelow reports whether key is within the upper bound b.
+func isBelow[K any](compare func(a, b K) int, key K, b Bound[K]) bool {
+	switch b.kind {
+	case inclusive:
+		return compare(key, b.key) <= 0
+	case exclusive:
+		return compare(key, b.key) < 0
+	default:
+		return true
+	}
+}
+
+// isAbove reports whether key is within the lower bound b.
+func isAbove[K any](compare func(a, b K) int, key K, b Bound[K]) bool {
+	switch b.kind {
+	case inclusive:
+		return compare(key, b.key) >= 0
+	case exclusive:
+		return compare(key, b.key) > 0
+	default:
+		return true
+	}
+}
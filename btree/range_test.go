@@ -0,0 +1,154 @@
+package btree_test
+
+import (
+	"iter"
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBTreeRange(t *testing.T) {
+	t.Run("it does not yield anything when the tree is empty", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+
+		// When
+		collected := collectKeys(bt.Range(1, 10))
+
+		// Then
+		require.Empty(t, collected)
+	})
+
+	t.Run("it yields the keys in the half-open range in ascending order", func(t *testing.T) {
+		propFunc := func(keys []int8, from, to int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+
+			// When
+			collected := collectKeys(bt.Range(from, to))
+
+			// Then
+			expected := filterKeys(cloneSortAndCompact(keys), func(key int8) bool {
+				return from <= key && key < to
+			})
+			return assert.Equal(t, expected, collected)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it stops when the loop breaks early", func(t *testing.T) {
+		// Given
+		bt := newBTreeFrom([]int{5, 3, 1, 4, 2})
+		collected := make([]int, 0)
+
+		// When
+		for key := range bt.Range(1, 6) {
+			if key > 3 {
+				break
+			}
+			collected = append(collected, key)
+		}
+
+		// Then
+		require.Equal(t, []int{1, 2, 3}, collected)
+	})
+}
+
+func TestBTreeAscend(t *testing.T) {
+	propFunc := func(keys []int8, from, to int8, fromKind, toKind uint8) bool {
+		// Given
+		bt := newBTreeFrom(keys)
+		lower, inLower := newBound(from, fromKind, func(a, b int8) bool { return a >= b })
+		upper, inUpper := newBound(to, toKind, func(a, b int8) bool { return a <= b })
+
+		// When
+		collected := collectKeys(bt.Ascend(lower, upper))
+
+		// Then
+		expected := filterKeys(cloneSortAndCompact(keys), func(key int8) bool {
+			return inLower(key) && inUpper(key)
+		})
+		return assert.Equal(t, expected, collected)
+	}
+
+	require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+}
+
+func TestBTreeDescend(t *testing.T) {
+	propFunc := func(keys []int8, from, to int8, fromKind, toKind uint8) bool {
+		// Given
+		bt := newBTreeFrom(keys)
+		upper, inUpper := newBound(from, fromKind, func(a, b int8) bool { return a <= b })
+		lower, inLower := newBound(to, toKind, func(a, b int8) bool { return a >= b })
+
+		// When
+		collected := collectKeys(bt.Descend(upper, lower))
+
+		// Then
+		expected := filterKeys(cloneSortAndCompact(keys), func(key int8) bool {
+			return inLower(key) && inUpper(key)
+		})
+		slices.Reverse(expected)
+		return assert.Equal(t, expected, collected)
+	}
+
+	require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+}
+
+func TestBTreeAll(t *testing.T) {
+	propFunc := func(keys []int8) bool {
+		// Given
+		bt := newBTreeFrom(keys)
+
+		// When
+		collected := make([]int8, 0)
+		for key, value := range bt.All() {
+			if !assert.Equal(t, key, value) {
+				return false
+			}
+			collected = append(collected, key)
+		}
+
+		// Then
+		return assert.Equal(t, cloneSortAndCompact(keys), collected)
+	}
+
+	require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+}
+
+// newBound makes one of the three kinds of bound for key, along with an oracle
+// that reports whether another key is within it. withinInclusive must compare a
+// key against an inclusive bound.
+func newBound[K comparable](key K, kind uint8, withinInclusive func(a, b K) bool) (btree.Bound[K], func(K) bool) {
+	switch kind % 3 {
+	case 0:
+		return btree.Unbounded[K](), func(K) bool { return true }
+	case 1:
+		return btree.Inclusive(key), func(k K) bool { return withinInclusive(k, key) }
+	default:
+		return btree.Exclusive(key), func(k K) bool { return k != key && withinInclusive(k, key) }
+	}
+}
+
+func collectKeys[K any, V any](seq iter.Seq2[K, V]) []K {
+	collected := make([]K, 0)
+	for key := range seq {
+		collected = append(collected, key)
+	}
+	return collected
+}
+
+func filterKeys[K any](keys []K, keep func(K) bool) []K {
+	filtered := make([]K, 0)
+	for _, key := range keys {
+		if keep(key) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,98 @@
+package btree
+
+// Rank returns the number of keys in the tree that are less than key, which is
+// also the index key has or would have in ascending key order.
+func (t *Tree[K, V]) Rank(key K) int {
+	return t.countBelow(key, false)
+}
+
+// Select returns the entry at index i in ascending key order, so that
+// Select(0) is the entry with the smallest key. It reports false when i is out
+// of range.
+func (t *Tree[K, V]) Select(i int) (K, V, bool) {
+	var zeroKey K
+	var zeroValue V
+	if i < 0 || i >= t.numEntries {
+		return zeroKey, zeroValue, false
+	}
+
+	p := t.root
+	for !p.isLeaf() {
+		// Skip each child subtree, and the entry that follows it, that comes
+		// wholly before index i.
+		j := 0
+		for i > p.children[j].count {
+			i -= p.children[j].count + 1
+			j += 1
+		}
+
+		if i == p.children[j].count {
+			e := p.entries[j]
+			return e.key, e.value, true
+		}
+
+		p = p.children[j]
+	}
+
+	e := p.entries[i]
+	return e.key, e.value, true
+}
+
+// CountRange returns the number of keys from the lower bound up to the upper
+// bound, without visiting them.
+func (t *Tree[K, V]) CountRange(from, to Bound[K]) int {
+	var below, within int
+
+	switch from.kind {
+	case inclusive:
+		below = t.countBelow(from.key, false)
+	case exclusive:
+		below = t.countBelow(from.key, true)
+	}
+
+	switch to.kind {
+	case inclusive:
+		within = t.countBelow(to.key, true)
+	case exclusive:
+		within = t.countBelow(to.key, false)
+	default:
+		within = t.numEntries
+	}
+
+	return max(within-below, 0)
+}
+
+// countBelow returns the number of keys less than key, or less than or equal
+// to key when orEqual is set. Whole subtrees to the left of the path to key are
+// counted from their pages' counts rather than visited.
+func (t *Tree[K, V]) countBelow(key K, orEqual bool) int {
+	compare := t.searchFunc(orEqual)
+
+	n := 0
+	for p := t.root; p != nil; {
+		i, found := p.search(key, compare)
+		n += i
+
+		if !p.isLeaf() {
+			for _, child := range p.children[:i] {
+				n += child.count
+			}
+		}
+
+		if found {
+			if !p.isLeaf() {
+				n += p.children[i].count
+			}
+			if orEqual {
+				n += 1
+			}
+			return n
+		}
+
+		if p.isLeaf() {
+			return n
+		}
+		p = p.children[i]
+	}
+	return n
+}
@@ -0,0 +1,127 @@
+package btree_test
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBTreeRank(t *testing.T) {
+	t.Run("it is zero when the tree is empty", func(t *testing.T) {
+		// Given
+		bt := btree.New[int, int]()
+
+		// When
+		rank := bt.Rank(123)
+
+		// Then
+		require.Zero(t, rank)
+	})
+
+	t.Run("it counts the keys less than the key", func(t *testing.T) {
+		propFunc := func(keys []int8, key int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+
+			// When
+			rank := bt.Rank(key)
+
+			// Then
+			expected := filterKeys(cloneSortAndCompact(keys), func(k int8) bool { return k < key })
+			return assert.Equal(t, len(expected), rank)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+}
+
+func TestBTreeSelect(t *testing.T) {
+	t.Run("it reports false when the index is out of range", func(t *testing.T) {
+		// Given
+		bt := newBTreeFrom([]int{1, 2, 3})
+
+		for _, i := range []int{-1, 3} {
+			// When
+			_, _, ok := bt.Select(i)
+
+			// Then
+			require.False(t, ok, "i=%d", i)
+		}
+	})
+
+	t.Run("it returns the entry at each index in ascending key order", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+
+			for i, expected := range cloneSortAndCompact(keys) {
+				// When
+				key, value, ok := bt.Select(i)
+
+				// Then
+				if !(assert.True(t, ok, "i=%d", i) && assert.Equal(t, expected, key) && assert.Equal(t, expected, value)) {
+					return false
+				}
+				if !assert.Equal(t, i, bt.Rank(key)) {
+					return false
+				}
+			}
+
+			return true
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it keeps track of the counts as keys are removed", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			sortedSetKeys := cloneSortAndCompact(keys)
+			removed := shuffled(sortedSetKeys)[:len(sortedSetKeys)/2]
+			bt := newBTreeFrom(keys)
+
+			// When
+			for _, key := range removed {
+				bt.Delete(key)
+			}
+
+			// Then
+			expected := filterKeys(sortedSetKeys, func(k int8) bool {
+				_, found := bt.Find(k)
+				return found
+			})
+			for i, key := range expected {
+				if selected, _, _ := bt.Select(i); !assert.Equal(t, key, selected, "i=%d", i) {
+					return false
+				}
+			}
+
+			return assert.NotPanics(t, bt.AssertInvariantsHold)
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+}
+
+func TestBTreeCountRange(t *testing.T) {
+	propFunc := func(keys []int8, from, to int8, fromKind, toKind uint8) bool {
+		// Given
+		bt := newBTreeFrom(keys)
+		lower, inLower := newBound(from, fromKind, func(a, b int8) bool { return a >= b })
+		upper, inUpper := newBound(to, toKind, func(a, b int8) bool { return a <= b })
+
+		// When
+		count := bt.CountRange(lower, upper)
+
+		// Then
+		expected := filterKeys(cloneSortAndCompact(keys), func(key int8) bool {
+			return inLower(key) && inUpper(key)
+		})
+		return assert.Equal(t, len(expected), count)
+	}
+
+	require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+}
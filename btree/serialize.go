@@ -0,0 +1,216 @@
+package btree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+var ErrInvalidStream = errors.New("btree: stream does not hold a valid tree")
+
+const (
+	streamMagic   = "GOKS"
+	streamVersion = 1
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Serializer writes a tree to a stream and reads it back, encoding keys and
+// values with its codecs.
+//
+// A stream starts with a magic number, a format version and the number of
+// entries. The entries follow in ascending key order, each as a
+// length-prefixed key and a length-prefixed value, and the stream ends with a
+// CRC-32C checksum of everything before it.
+type Serializer[K any, V any] struct {
+	tree   *Tree[K, V]
+	keys   Codec[K]
+	values Codec[V]
+}
+
+// Serializer returns a Serializer that writes and reads the tree using the
+// given codecs for its keys and values.
+func (t *Tree[K, V]) Serializer(keys Codec[K], values Codec[V]) Serializer[K, V] {
+	return Serializer[K, V]{tree: t, keys: keys, values: values}
+}
+
+// WriteTo writes every entry of the tree to w, returning the number of bytes
+// written.
+func (s Serializer[K, V]) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	sum := crc32.New(castagnoli)
+	out := io.MultiWriter(bw, sum)
+
+	buf := []byte(streamMagic)
+	buf = binary.BigEndian.AppendUint16(buf, streamVersion)
+	buf = binary.AppendUvarint(buf, uint64(s.tree.Len()))
+	if _, err := out.Write(buf); err != nil {
+		return cw.n, err
+	}
+
+	var err error
+	for key, value := range s.tree.All() {
+		if buf, err = appendLengthPrefixed(buf[:0], s.keys, key); err != nil {
+			return cw.n, err
+		}
+		if buf, err = appendLengthPrefixed(buf, s.values, value); err != nil {
+			return cw.n, err
+		}
+		if _, err = out.Write(buf); err != nil {
+			return cw.n, err
+		}
+	}
+
+	if _, err = bw.Write(binary.BigEndian.AppendUint32(buf[:0], sum.Sum32())); err != nil {
+		return cw.n, err
+	}
+
+	err = bw.Flush()
+	return cw.n, err
+}
+
+// ReadFrom fills an empty tree with the entries written to r by WriteTo,
+// returning the number of bytes read. The entries are bulk loaded, so must be
+// in ascending order according to the tree's comparator.
+//
+// It returns an error, leaving the tree empty, if the tree already has entries
+// or if the stream is not valid. If r does not implement io.ByteReader, ReadFrom
+// may read beyond the end of the tree.
+func (s Serializer[K, V]) ReadFrom(r io.Reader) (int64, error) {
+	if s.tree.root != nil {
+		return 0, ErrNotEmpty
+	}
+
+	sr := newStreamReader(r)
+
+	header := make([]byte, len(streamMagic)+2)
+	if _, err := io.ReadFull(sr, header); err != nil {
+		return sr.n, invalidStream(err)
+	}
+	if string(header[:len(streamMagic)]) != streamMagic {
+		return sr.n, fmt.Errorf("%w: bad magic %q", ErrInvalidStream, header[:len(streamMagic)])
+	}
+	if version := binary.BigEndian.Uint16(header[len(streamMagic):]); version != streamVersion {
+		return sr.n, fmt.Errorf("%w: unsupported version %d", ErrInvalidStream, version)
+	}
+
+	count, err := binary.ReadUvarint(sr)
+	if err != nil {
+		return sr.n, invalidStream(err)
+	}
+
+	// The count is not trusted to size the entries up front, as a corrupt one
+	// could be enormous.
+	entries := make([]entry[K, V], 0, min(count, 1024))
+	for ; count > 0; count -= 1 {
+		var e entry[K, V]
+		if e.key, err = readLengthPrefixed(sr, s.keys); err != nil {
+			return sr.n, invalidStream(err)
+		}
+		if e.value, err = readLengthPrefixed(sr, s.values); err != nil {
+			return sr.n, invalidStream(err)
+		}
+		entries = append(entries, e)
+	}
+
+	expected := sr.sum.Sum32()
+	trailer := make([]byte, 4)
+	if _, err := io.ReadFull(sr, trailer); err != nil {
+		return sr.n, invalidStream(err)
+	}
+	if checksum := binary.BigEndian.Uint32(trailer); checksum != expected {
+		return sr.n, fmt.Errorf("%w: checksum is %08x, expected %08x", ErrInvalidStream, checksum, expected)
+	}
+
+	err = s.tree.Load(func(yield func(K, V) bool) {
+		for _, e := range entries {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	})
+	return sr.n, err
+}
+
+func invalidStream(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidStream, err)
+}
+
+func readLengthPrefixed[T any](r *streamReader, codec Codec[T]) (T, error) {
+	var zero T
+
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return zero, err
+	}
+	if length > math.MaxInt32 {
+		return zero, fmt.Errorf("length %d is too large", length)
+	}
+
+	// The length is not trusted to size the buffer up front either, so that
+	// it only grows as the data arrives.
+	var data bytes.Buffer
+	if _, err := io.CopyN(&data, r, int64(length)); err != nil {
+		return zero, err
+	}
+
+	return codec.Decode(data.Bytes())
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// streamReader counts and checksums the bytes read through it.
+type streamReader struct {
+	r   byteReader
+	sum hash.Hash32
+	n   int64
+}
+
+func newStreamReader(r io.Reader) *streamReader {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &streamReader{r: br, sum: crc32.New(castagnoli)}
+}
+
+func (sr *streamReader) Read(p []byte) (int, error) {
+	n, err := sr.r.Read(p)
+	sr.sum.Write(p[:n])
+	sr.n += int64(n)
+	return n, err
+}
+
+func (sr *streamReader) ReadByte() (byte, error) {
+	b, err := sr.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	sr.sum.Write([]byte{b})
+	sr.n += 1
+	return b, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
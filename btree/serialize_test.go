@@ -0,0 +1,143 @@
+package btree_test
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializer(t *testing.T) {
+	int8Serializer := func(bt *btree.Tree[int8, int8]) btree.Serializer[int8, int8] {
+		return bt.Serializer(btree.BinaryCodec[int8]{}, btree.BinaryCodec[int8]{})
+	}
+
+	t.Run("a tree read back has the same entries as the one written", func(t *testing.T) {
+		propFunc := func(keys []int8) bool {
+			// Given
+			bt := newBTreeFrom(keys)
+			var buf bytes.Buffer
+
+			// When
+			written, writeErr := int8Serializer(&bt).WriteTo(&buf)
+			size := int64(buf.Len())
+			readBack := btree.New[int8, int8]()
+			read, readErr := int8Serializer(&readBack).ReadFrom(&buf)
+
+			// Then
+			return assert.NoError(t, writeErr) &&
+				assert.NoError(t, readErr) &&
+				assert.Equal(t, size, written) &&
+				assert.Equal(t, size, read) &&
+				assert.NotPanics(t, readBack.AssertInvariantsHold) &&
+				assert.Equal(t, collectEntries(&bt), collectEntries(&readBack))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("it encodes keys and values with the given codecs", func(t *testing.T) {
+		type point struct{ X, Y int }
+
+		for name, values := range map[string]btree.Codec[point]{
+			"gob":  btree.GobCodec[point]{},
+			"json": btree.JSONCodec[point]{},
+		} {
+			// Given
+			bt := btree.New[string, point]()
+			bt.Insert("origin", point{0, 0})
+			bt.Insert("unit", point{1, 1})
+			var buf bytes.Buffer
+
+			// When
+			_, err := bt.Serializer(btree.StringCodec{}, values).WriteTo(&buf)
+			require.NoError(t, err, name)
+			readBack := btree.New[string, point]()
+			_, err = readBack.Serializer(btree.StringCodec{}, values).ReadFrom(&buf)
+
+			// Then
+			require.NoError(t, err, name)
+			value, found := readBack.Find("unit")
+			require.True(t, found, name)
+			require.Equal(t, point{1, 1}, value, name)
+			require.Equal(t, 2, readBack.Len(), name)
+		}
+	})
+
+	t.Run("it keeps the order of equal keys in a multi tree", func(t *testing.T) {
+		// Given
+		bt, oracle := newMultiTreeFrom([]uint8{3, 1, 3, 2, 1, 3})
+		var buf bytes.Buffer
+		_, err := bt.Serializer(btree.BinaryCodec[uint8]{}, btree.GobCodec[int]{}).WriteTo(&buf)
+		require.NoError(t, err)
+
+		// When
+		readBack := btree.NewMulti[uint8, int]()
+		_, err = readBack.Serializer(btree.BinaryCodec[uint8]{}, btree.GobCodec[int]{}).ReadFrom(&buf)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, oracle, collectPairs(&readBack))
+	})
+
+	t.Run("it does not read into a tree that has entries", func(t *testing.T) {
+		// Given
+		bt := newBTreeFrom([]int8{1})
+
+		// When
+		_, err := int8Serializer(&bt).ReadFrom(bytes.NewReader(nil))
+
+		// Then
+		require.ErrorIs(t, err, btree.ErrNotEmpty)
+	})
+
+	t.Run("it rejects a damaged stream", func(t *testing.T) {
+		// Given
+		bt := newBTreeFrom([]int8{1, 2, 3, 4, 5})
+		var buf bytes.Buffer
+		_, err := int8Serializer(&bt).WriteTo(&buf)
+		require.NoError(t, err)
+		written := buf.Bytes()
+
+		for i := range written {
+			for name, damaged := range map[string][]byte{
+				"truncated": written[:i],
+				"corrupted": flipBit(written, i),
+			} {
+				// When
+				readBack := btree.New[int8, int8]()
+				_, err := int8Serializer(&readBack).ReadFrom(bytes.NewReader(damaged))
+
+				// Then
+				require.ErrorIs(t, err, btree.ErrInvalidStream, "%s at %d", name, i)
+				require.Zero(t, readBack.Len(), "%s at %d", name, i)
+			}
+		}
+	})
+
+	t.Run("it does not trust the length of a key to allocate memory", func(t *testing.T) {
+		// Given a stream of one entry whose key claims to be 2GB long
+		forged := []byte("GOKS\x00\x01\x01\xff\xff\xff\xff\x07")
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		// When
+		readBack := btree.New[int8, int8]()
+		_, err := int8Serializer(&readBack).ReadFrom(bytes.NewReader(forged))
+
+		// Then
+		runtime.ReadMemStats(&after)
+		require.ErrorIs(t, err, btree.ErrInvalidStream)
+		require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+	})
+}
+
+func flipBit(data []byte, i int) []byte {
+	flipped := bytes.Clone(data)
+	flipped[i] ^= 0x10
+	return flipped
+}
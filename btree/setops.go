@@ -0,0 +1,87 @@
+package btree
+
+// Union returns a new tree holding the entries of both t and other. Where both
+// have an entry with the same key, the new tree holds a single entry for it
+// with the value returned by resolve.
+//
+// The new tree has the same order and comparator as t, which other must share.
+// It is built in time linear in the sizes of both trees. In multi trees, the
+// entries with equal keys in each tree are paired off in order.
+func (t *Tree[K, V]) Union(other *Tree[K, V], resolve func(key K, mine, theirs V) V) Tree[K, V] {
+	entries := make([]entry[K, V], 0, t.Len()+other.Len())
+	add := func(e *entry[K, V]) { entries = append(entries, *e) }
+
+	t.mergeWith(other, add, add, func(mine, theirs *entry[K, V]) {
+		entries = append(entries, entry[K, V]{key: mine.key, value: resolve(mine.key, mine.value, theirs.value)})
+	})
+
+	return t.newLike(entries)
+}
+
+// Intersect returns a new tree holding an entry for each key that both t and
+// other have, with the value returned by resolve. It is built in the same way
+// as by Union.
+func (t *Tree[K, V]) Intersect(other *Tree[K, V], resolve func(key K, mine, theirs V) V) Tree[K, V] {
+	entries := make([]entry[K, V], 0, min(t.Len(), other.Len()))
+
+	t.mergeWith(other, nil, nil, func(mine, theirs *entry[K, V]) {
+		entries = append(entries, entry[K, V]{key: mine.key, value: resolve(mine.key, mine.value, theirs.value)})
+	})
+
+	return t.newLike(entries)
+}
+
+// Difference returns a new tree holding the entries of t whose keys other does
+// not have. It is built in the same way as by Union.
+func (t *Tree[K, V]) Difference(other *Tree[K, V]) Tree[K, V] {
+	entries := make([]entry[K, V], 0, t.Len())
+
+	t.mergeWith(other, func(e *entry[K, V]) { entries = append(entries, *e) }, nil, nil)
+
+	return t.newLike(entries)
+}
+
+// mergeWith walks the entries of t and other together in ascending key order,
+// calling onlyMine or onlyTheirs for an entry whose key is in just one of the
+// trees, and both for a pair of entries with equal keys. Walking stops as soon
+// as the calls left to make are to nil functions.
+func (t *Tree[K, V]) mergeWith(other *Tree[K, V], onlyMine, onlyTheirs func(e *entry[K, V]), both func(mine, theirs *entry[K, V])) {
+	mine, theirs := t.Cursor(), other.Cursor()
+	okMine, okTheirs := mine.First(), theirs.First()
+
+	for okMine && okTheirs {
+		switch c := t.compare(mine.Key(), theirs.Key()); {
+		case c < 0:
+			if onlyMine != nil {
+				onlyMine(mine.current())
+			}
+			okMine = mine.Next()
+		case c > 0:
+			if onlyTheirs != nil {
+				onlyTheirs(theirs.current())
+			}
+			okTheirs = theirs.Next()
+		default:
+			if both != nil {
+				both(mine.current(), theirs.current())
+			}
+			okMine, okTheirs = mine.Next(), theirs.Next()
+		}
+	}
+
+	for ; okMine && onlyMine != nil; okMine = mine.Next() {
+		onlyMine(mine.current())
+	}
+
+	for ; okTheirs && onlyTheirs != nil; okTheirs = theirs.Next() {
+		onlyTheirs(theirs.current())
+	}
+}
+
+// newLike returns a new tree with the same order, comparator and mode as t,
+// holding entries, which are already in order.
+func (t *Tree[K, V]) newLike(entries []entry[K, V]) Tree[K, V] {
+	result := Tree[K, V]{order: t.order, compare: t.compare, owner: new(owner), multi: t.multi}
+	result.build(entries)
+	return result
+}
@@ -0,0 +1,116 @@
+package btree_test
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/btree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBTreeSetOperations(t *testing.T) {
+	sum := func(_ int8, mine, theirs int8) int8 { return mine + theirs }
+
+	t.Run("Union holds the entries of both trees, resolving shared keys", func(t *testing.T) {
+		propFunc := func(a, b []int8) bool {
+			// Given
+			mine, theirs := newBTreeFrom(a), newBTreeFrom(b)
+
+			// When
+			union := mine.Union(&theirs, sum)
+
+			// Then
+			expected := newOracleFrom(a)
+			for key, value := range newOracleFrom(b) {
+				if existing, ok := expected[key]; ok {
+					value += existing
+				}
+				expected[key] = value
+			}
+			return assert.NotPanics(t, union.AssertInvariantsHold) &&
+				assert.Equal(t, len(expected), union.Len()) &&
+				assert.Equal(t, expected, collectEntries(&union))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("Intersect holds the shared keys, resolving their values", func(t *testing.T) {
+		propFunc := func(a, b []int8) bool {
+			// Given
+			mine, theirs := newBTreeFrom(a), newBTreeFrom(b)
+
+			// When
+			intersection := mine.Intersect(&theirs, sum)
+
+			// Then
+			expected := make(map[int8]int8)
+			theirsOracle := newOracleFrom(b)
+			for key, value := range newOracleFrom(a) {
+				if other, ok := theirsOracle[key]; ok {
+					expected[key] = value + other
+				}
+			}
+			return assert.NotPanics(t, intersection.AssertInvariantsHold) &&
+				assert.Equal(t, len(expected), intersection.Len()) &&
+				assert.Equal(t, expected, collectEntries(&intersection))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("Difference holds the entries whose keys are only in the first tree", func(t *testing.T) {
+		propFunc := func(a, b []int8) bool {
+			// Given
+			mine, theirs := newBTreeFrom(a), newBTreeFrom(b)
+
+			// When
+			difference := mine.Difference(&theirs)
+
+			// Then
+			expected := newOracleFrom(a)
+			for _, key := range b {
+				delete(expected, key)
+			}
+			return assert.NotPanics(t, difference.AssertInvariantsHold) &&
+				assert.Equal(t, len(expected), difference.Len()) &&
+				assert.Equal(t, expected, collectEntries(&difference))
+		}
+
+		require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+	})
+
+	t.Run("the result is independent of both trees", func(t *testing.T) {
+		// Given
+		mine, theirs := newBTreeFrom([]int8{1, 2, 3}), newBTreeFrom([]int8{3, 4})
+		union := mine.Union(&theirs, sum)
+
+		// When
+		union.Insert(5, 5)
+		mine.Delete(1)
+		theirs.Insert(6, 6)
+
+		// Then
+		require.Equal(t, []int8{1, 2, 3, 4, 5}, collectKeys(union.All()))
+	})
+
+	t.Run("the result keeps the order of the first tree", func(t *testing.T) {
+		// Given
+		descending := func(a, b int) int { return b - a }
+		mine := btree.NewFuncWithOrder[int, int](3, descending)
+		theirs := btree.NewFunc[int, int](descending)
+		for i := 0; i < 50; i += 1 {
+			mine.Insert(i*2, i)
+			theirs.Insert(i*3, i)
+		}
+
+		// When
+		union := mine.Union(&theirs, func(_ int, mine, _ int) int { return mine })
+
+		// Then
+		require.NotPanics(t, union.AssertInvariantsHold)
+		require.Equal(t, 147, collectKeys(union.All())[0])
+		require.Equal(t, 83, union.Len())
+	})
+}
@@ -0,0 +1,163 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvariant is wrapped by every InvariantError, so that a failed validation
+// can be detected with errors.Is without knowing the key type.
+var ErrInvariant = errors.New("btree: invariant does not hold")
+
+// InvariantError describes the first invariant that Validate found not to hold.
+type InvariantError[K any] struct {
+	// Invariant names the rule that was broken.
+	Invariant string
+
+	// Depth is the depth of the offending page, where the root is at depth 0.
+	// It is -1 when the problem is with the tree as a whole.
+	Depth int
+
+	// Keys holds the keys of the offending page.
+	Keys []K
+}
+
+func (e *InvariantError[K]) Error() string {
+	if e.Depth < 0 {
+		return fmt.Sprintf("%v: %s", ErrInvariant, e.Invariant)
+	}
+	return fmt.Sprintf("%v: %s at depth %d in page with keys %v", ErrInvariant, e.Invariant, e.Depth, e.Keys)
+}
+
+func (e *InvariantError[K]) Unwrap() error {
+	return ErrInvariant
+}
+
+// Validate checks the structure of the tree, returning an *InvariantError for
+// the first invariant that does not hold, or nil if the tree is sound. It visits
+// every page, so takes time proportional to the size of the tree.
+func (t *Tree[K, V]) Validate() error {
+	v := validator[K, V]{order: t.order, compare: t.compare, multi: t.multi, leafDepth: -1}
+
+	if t.root != nil {
+		if err := v.validateSubtree(t.root, 0, nil, nil); err != nil {
+			return err
+		}
+
+		// The root holds at least one entry, so has at least two children if it is
+		// not a leaf. An empty tree has no root at all.
+		if len(t.root.entries) == 0 {
+			return v.fail("root is empty", 0, t.root)
+		}
+	}
+
+	if v.numEntries != t.numEntries {
+		return v.fail(fmt.Sprintf("tree has %d entries but Len is %d", v.numEntries, t.numEntries), -1, nil)
+	}
+
+	return nil
+}
+
+type validator[K any, V any] struct {
+	order      order
+	compare    func(a, b K) int
+	multi      bool
+	leafDepth  int
+	numEntries int
+}
+
+// validateSubtree checks the subtree rooted at p, whose keys must all lie
+// between lo and hi where they are given. Children are checked before
+// their parent, so that a problem is reported against the page that has it.
+func (v *validator[K, V]) validateSubtree(p *page[K, V], depth int, lo, hi *K) error {
+	// A non-leaf page with n children contains n-1 entries. This is checked
+	// first as the bounds of each child are found from the entries.
+	if !p.isLeaf() && len(p.entries) != len(p.children)-1 {
+		return v.fail("n children but not n-1 entries", depth, p)
+	}
+
+	for i, child := range p.children {
+		childLo, childHi := lo, hi
+		if i > 0 {
+			childLo = &p.entries[i-1].key
+		}
+		if i < len(p.entries) {
+			childHi = &p.entries[i].key
+		}
+
+		if err := v.validateSubtree(child, depth+1, childLo, childHi); err != nil {
+			return err
+		}
+	}
+
+	v.numEntries += len(p.entries)
+	return v.validatePage(p, depth, lo, hi)
+}
+
+func (v *validator[K, V]) validatePage(p *page[K, V], depth int, lo, hi *K) error {
+	isRoot := depth == 0
+	o := v.order
+
+	// Every page has at most 2k entries and 2k+1 children, and room for only one
+	// more of each.
+	if len(p.entries) > o.maxEntries || cap(p.entries) > o.maxEntries+1 {
+		return v.fail("too many entries", depth, p)
+	}
+	if len(p.children) > o.maxChildren || cap(p.children) > o.maxChildren+1 {
+		return v.fail("too many children", depth, p)
+	}
+
+	// Every page except the root has at least k entries.
+	if !isRoot && len(p.entries) < o.minEntries {
+		return v.fail("too few entries", depth, p)
+	}
+
+	// Every leaf is at the same depth.
+	if p.isLeaf() {
+		if v.leafDepth < 0 {
+			v.leafDepth = depth
+		} else if depth != v.leafDepth {
+			return v.fail(fmt.Sprintf("leaf at depth %d, expected %d", depth, v.leafDepth), depth, p)
+		}
+	}
+
+	// Keys ascend within the page and lie between the parent's entries either
+	// side of it.
+	for i, e := range p.entries {
+		if i > 0 && !v.ascending(p.entries[i-1].key, e.key) {
+			return v.fail("keys out of order", depth, p)
+		}
+		if lo != nil && !v.ascending(*lo, e.key) {
+			return v.fail("key not greater than parent entry", depth, p)
+		}
+		if hi != nil && !v.ascending(e.key, *hi) {
+			return v.fail("key not less than parent entry", depth, p)
+		}
+	}
+
+	// Every page counts the entries in its subtree.
+	count := len(p.entries)
+	for _, child := range p.children {
+		count += child.count
+	}
+	if p.count != count {
+		return v.fail(fmt.Sprintf("page counts %d entries in its subtree, expected %d", p.count, count), depth, p)
+	}
+
+	return nil
+}
+
+// ascending reports whether a can come before b, which in a multi tree
+// includes when they are equal.
+func (v *validator[K, V]) ascending(a, b K) bool {
+	c := v.compare(a, b)
+	return c < 0 || (c == 0 && v.multi)
+}
+
+func (v *validator[K, V]) fail(invariant string, depth int, p *page[K, V]) error {
+	err := &InvariantError[K]{Invariant: invariant, Depth: depth}
+	if p != nil && len(p.entries) > 0 {
+		err.Keys = p.keys()
+	}
+	return err
+}
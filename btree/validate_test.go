@@ -0,0 +1,167 @@
+package btree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeValidate(t *testing.T) {
+	t.Run("an empty tree is valid", func(t *testing.T) {
+		// Given
+		bt := New[int, int]()
+
+		// When
+		err := bt.Validate()
+
+		// Then
+		require.NoError(t, err)
+	})
+
+	t.Run("a tree built by inserting keys is valid", func(t *testing.T) {
+		// Given
+		bt := newValidationTree()
+
+		// When
+		err := bt.Validate()
+
+		// Then
+		require.NoError(t, err)
+	})
+
+	for _, tc := range []struct {
+		name      string
+		corrupt   func(bt *Tree[int, int])
+		invariant string
+		depth     int
+		keys      []int
+	}{
+		{
+			name:      "Len does not match the entries",
+			corrupt:   func(bt *Tree[int, int]) { bt.numEntries += 1 },
+			invariant: "tree has 20 entries but Len is 21",
+			depth:     -1,
+		},
+		{
+			name: "keys are out of order within a page",
+			corrupt: func(bt *Tree[int, int]) {
+				leaf := bt.root.children[0].children[0]
+				leaf.entries[0], leaf.entries[1] = leaf.entries[1], leaf.entries[0]
+			},
+			invariant: "keys out of order",
+			depth:     2,
+			keys:      []int{2, 1},
+		},
+		{
+			name: "a key is on the wrong side of its parent entry",
+			corrupt: func(bt *Tree[int, int]) {
+				bt.root.children[0].children[0].entries.last().key = 100
+			},
+			invariant: "key not less than parent entry",
+			depth:     2,
+			keys:      []int{1, 100},
+		},
+		{
+			name: "a page miscounts its subtree",
+			corrupt: func(bt *Tree[int, int]) {
+				bt.root.children[1].count -= 1
+			},
+			invariant: "page counts 5 entries in its subtree, expected 6",
+			depth:     1,
+			keys:      []int{10, 12},
+		},
+		{
+			name: "a page has too few entries",
+			corrupt: func(bt *Tree[int, int]) {
+				leaf := bt.root.children[1].children[1]
+				leaf.entries.pop()
+				leaf.count -= 1
+			},
+			invariant: "too few entries",
+			depth:     2,
+		},
+		{
+			name: "a page has one child too many",
+			corrupt: func(bt *Tree[int, int]) {
+				p := bt.root.children[0]
+				p.children = append(p.children, p.children[0])
+			},
+			invariant: "n children but not n-1 entries",
+			depth:     1,
+			keys:      []int{3, 5},
+		},
+	} {
+		t.Run("it reports when "+tc.name, func(t *testing.T) {
+			// Given
+			bt := newValidationTree()
+			tc.corrupt(&bt)
+
+			// When
+			err := bt.Validate()
+
+			// Then
+			require.ErrorIs(t, err, ErrInvariant)
+
+			var invariantErr *InvariantError[int]
+			require.True(t, errors.As(err, &invariantErr))
+			require.Equal(t, tc.invariant, invariantErr.Invariant)
+			require.Equal(t, tc.depth, invariantErr.Depth)
+			require.Equal(t, tc.keys, invariantErr.Keys)
+		})
+	}
+}
+
+func TestBPlusTreeValidate(t *testing.T) {
+	t.Run("a tree built by inserting keys is valid", func(t *testing.T) {
+		// Given
+		bpt := newBPlusValidationTree()
+
+		// When
+		err := bpt.Validate()
+
+		// Then
+		require.NoError(t, err)
+	})
+
+	t.Run("it reports when a page has one child too many", func(t *testing.T) {
+		// Given
+		bpt := newBPlusValidationTree()
+		p := bpt.root.children[0]
+		p.children = append(p.children, p.children[0])
+
+		// When
+		err := bpt.Validate()
+
+		// Then
+		var invariantErr *InvariantError[int]
+		require.True(t, errors.As(err, &invariantErr))
+		require.Equal(t, "n children but not n-1 keys", invariantErr.Invariant)
+		require.Equal(t, 1, invariantErr.Depth)
+	})
+}
+
+// newBPlusValidationTree returns a B+tree of order 1 holding the keys 1 to 20,
+// which is several levels deep.
+func newBPlusValidationTree() BPlusTree[int, int] {
+	bpt := NewBPlus[int, int]()
+	for key := 1; key <= 20; key += 1 {
+		bpt.Insert(key, key)
+	}
+	return bpt
+}
+
+// newValidationTree returns a tree of order 1 holding the keys 1 to 20, which
+// is three levels deep with two entries in the first leaf.
+func newValidationTree() Tree[int, int] {
+	pairs := make([]Pair[int, int], 0, 20)
+	for key := 1; key <= 20; key += 1 {
+		pairs = append(pairs, Pair[int, int]{Key: key, Value: key})
+	}
+
+	bt, err := FromSorted(pairs)
+	if err != nil {
+		panic(err)
+	}
+	return bt
+}
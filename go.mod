@@ -4,6 +4,7 @@ go 1.24
 
 require (
 	github.com/onsi/gomega v1.10.1
+	github.com/stretchr/testify v1.8.4
 	golang.org/x/exp v0.0.0-20221126150942-6ab00d035af9
 )
 
@@ -11,7 +12,6 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.0 // indirect
-	github.com/stretchr/testify v1.8.4 // indirect
 	golang.org/x/net v0.0.0-20200520004742-59133d7f0dd7 // indirect
 	golang.org/x/text v0.3.0 // indirect
 	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
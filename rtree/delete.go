@@ -0,0 +1,93 @@
+package rtree
+
+import "slices"
+
+// Delete removes item, which was inserted with the bounding rectangle bbox,
+// from the tree. Items are matched by identity, so only an entry holding the
+// same pointer is removed. It reports whether there was such an entry.
+func (t *RTree[T]) Delete(bbox Rect, item *T) bool {
+	if t.root == nil {
+		return false
+	}
+
+	leaf, i, path := t.root.findLeaf(bbox, item, nil)
+	if leaf == nil {
+		return false
+	}
+
+	leaf.removeAt(i)
+	t.size -= 1
+	t.condenseTree(leaf, path)
+
+	// A root left with a single child is replaced by the child, shortening the
+	// tree, and a tree left with nothing in it has no root, like a new one.
+	for !t.root.isLeaf() && len(t.root.children) == 1 {
+		t.root = t.root.children[0]
+	}
+	if len(t.root.bbox) == 0 {
+		t.root = nil
+	}
+
+	return true
+}
+
+// findLeaf searches the subtree for the leaf holding item with the bounding
+// rectangle bbox, descending only into entries whose rectangles contain bbox.
+// It returns the leaf, the index of the entry and the path to the leaf, or a
+// nil leaf if there is no such entry.
+func (n *node[T]) findLeaf(bbox Rect, item *T, path []pathFrame[T]) (*node[T], int, []pathFrame[T]) {
+	if n.isLeaf() {
+		for i := range n.bbox {
+			if n.data[i] == item && n.bbox[i] == bbox {
+				return n, i, path
+			}
+		}
+		return nil, 0, nil
+	}
+
+	for i, childBbox := range n.bbox {
+		if !childBbox.Contains(bbox) {
+			continue
+		}
+
+		if leaf, j, leafPath := n.children[i].findLeaf(bbox, item, append(path, pathFrame[T]{node: n, index: i})); leaf != nil {
+			return leaf, j, leafPath
+		}
+	}
+
+	return nil, 0, nil
+}
+
+func (n *node[T]) removeAt(i int) {
+	n.bbox = slices.Delete(n.bbox, i, i+1)
+	if n.isLeaf() {
+		n.data = slices.Delete(n.data, i, i+1)
+	} else {
+		n.children = slices.Delete(n.children, i, i+1)
+	}
+}
+
+// condenseTree ascends from n, which has just lost an entry, to the root. A
+// node left with too few entries is taken out of its parent and its entries
+// are inserted again at the same height once the ascent is done, and the
+// bounding rectangle of any other node is tightened in its parent.
+func (t *RTree[T]) condenseTree(n *node[T], path []pathFrame[T]) {
+	var orphans []pendingEntry[T]
+
+	for d, height := len(path)-1, 0; d >= 0; d, height = d-1, height+1 {
+		parent, i := path[d].node, path[d].index
+
+		if len(n.bbox) < t.opts.minEntries() {
+			parent.removeAt(i)
+			for _, e := range n.entries() {
+				orphans = append(orphans, pendingEntry[T]{entry: e, height: height})
+			}
+		} else {
+			parent.bbox[i] = n.bounds()
+		}
+
+		n = parent
+	}
+
+	t.insertAll(orphans)
+}
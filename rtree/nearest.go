@@ -0,0 +1,121 @@
+package rtree
+
+import (
+	"container/heap"
+	"iter"
+	"math"
+)
+
+// DistanceFunc returns the distance from the point (x, y) to item, which is in
+// the tree with the bounding rectangle bbox. It must never be less than the
+// distance from the point to bbox, for items to come out in order.
+type DistanceFunc[T any] func(x, y float64, bbox Rect, item *T) float64
+
+// Nearest returns the k items nearest to the point (x, y), nearest first,
+// measuring the distance to each by its bounding rectangle. It returns fewer
+// if the tree has fewer than k items.
+func (t *RTree[T]) Nearest(x, y float64, k int) []*T {
+	return t.NearestFunc(x, y, k, nil)
+}
+
+// NearestFunc is like Nearest but measures the distance to each item with
+// distance, as NearestIter does, so that items that are not points are ranked
+// by how far away they really are rather than by their bounding rectangles.
+func (t *RTree[T]) NearestFunc(x, y float64, k int, distance DistanceFunc[T]) []*T {
+	nearest := make([]*T, 0, max(0, min(k, t.size)))
+	if k <= 0 {
+		return nearest
+	}
+
+	for item := range t.NearestIter(x, y, distance) {
+		nearest = append(nearest, item)
+		if len(nearest) == k {
+			break
+		}
+	}
+	return nearest
+}
+
+// NearestIter returns an iterator over every item in the tree and its distance
+// from the point (x, y), in order of increasing distance. The distance to an
+// item is given by distance, or is the distance to its bounding rectangle if
+// distance is nil, which for an item that is a point is the distance to it.
+//
+// Nodes are only visited when the iteration gets as far as their rectangles,
+// so stopping early after the first few items visits little of the tree.
+func (t *RTree[T]) NearestIter(x, y float64, distance DistanceFunc[T]) iter.Seq2[*T, float64] {
+	if distance == nil {
+		distance = func(x, y float64, bbox Rect, _ *T) float64 {
+			return bbox.minDist(x, y)
+		}
+	}
+
+	return func(yield func(*T, float64) bool) {
+		if t.root == nil {
+			return
+		}
+
+		// A best-first search: the queue holds nodes by the least distance any
+		// of their items can be, and items by their distance, so an item comes
+		// off the queue only once nothing left in it can be nearer.
+		queue := nearestQueue[T]{{node: t.root, distance: t.root.bounds().minDist(x, y)}}
+		for len(queue) > 0 {
+			next := heap.Pop(&queue).(nearestCandidate[T])
+			if next.node == nil {
+				if !yield(next.item, next.distance) {
+					return
+				}
+				continue
+			}
+
+			n := next.node
+			for i, bbox := range n.bbox {
+				if n.isLeaf() {
+					heap.Push(&queue, nearestCandidate[T]{item: n.data[i], distance: distance(x, y, bbox, n.data[i])})
+				} else {
+					heap.Push(&queue, nearestCandidate[T]{node: n.children[i], distance: bbox.minDist(x, y)})
+				}
+			}
+		}
+	}
+}
+
+// minDist returns the distance from the point (x, y) to the nearest point of
+// r, which is zero if r contains it.
+func (r Rect) minDist(x, y float64) float64 {
+	dx := math.Max(0, math.Max(r.xMin-x, x-r.xMax))
+	dy := math.Max(0, math.Max(r.yMin-y, y-r.yMax))
+	return math.Hypot(dx, dy)
+}
+
+// nearestCandidate is either a node or an item waiting in a nearestQueue.
+type nearestCandidate[T any] struct {
+	distance float64
+	node     *node[T]
+	item     *T
+}
+
+// nearestQueue is a min-heap of candidates by distance, implementing
+// heap.Interface. Items come before nodes at the same distance, so that they
+// are yielded without first visiting the nodes.
+type nearestQueue[T any] []nearestCandidate[T]
+
+func (q nearestQueue[T]) Len() int { return len(q) }
+
+func (q nearestQueue[T]) Less(i, j int) bool {
+	if q[i].distance != q[j].distance {
+		return q[i].distance < q[j].distance
+	}
+	return q[i].node == nil && q[j].node != nil
+}
+
+func (q nearestQueue[T]) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *nearestQueue[T]) Push(x any) { *q = append(*q, x.(nearestCandidate[T])) }
+
+func (q *nearestQueue[T]) Pop() any {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,183 @@
+package rtree
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// InsertionPolicy chooses how entries are placed in the tree.
+type InsertionPolicy int
+
+const (
+	// GuttmanInsertion places each entry in the node whose rectangle it
+	// enlarges least, and splits nodes that overflow using the tree's
+	// SplitStrategy.
+	GuttmanInsertion InsertionPolicy = iota
+
+	// RStarInsertion follows the R*-tree of Beckmann et al. It places entries
+	// in leaves so as to least increase the overlap between them, takes some
+	// entries out of a node that overflows to be inserted again the first time
+	// it happens at each level during an insertion, and otherwise splits nodes
+	// along the axis and at the point that give the smallest margins and
+	// overlap.
+	RStarInsertion
+)
+
+// reinsertCount returns the number of entries taken out of an overflowing node
+// to be inserted again, which is 30% of the entries as the R*-tree paper
+// recommends, but at least one.
+func (o Options) reinsertCount() int {
+	return max(1, (o.maxEntries()+1)*3/10)
+}
+
+func (r Rect) margin() float64 {
+	return 2 * ((r.xMax - r.xMin) + (r.yMax - r.yMin))
+}
+
+// overlap returns the area that r and other have in common.
+func (r Rect) overlap(other Rect) float64 {
+	width := math.Min(r.xMax, other.xMax) - math.Max(r.xMin, other.xMin)
+	height := math.Min(r.yMax, other.yMax) - math.Max(r.yMin, other.yMin)
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+	return width * height
+}
+
+func (r Rect) centre() (float64, float64) {
+	return (r.xMin + r.xMax) / 2, (r.yMin + r.yMax) / 2
+}
+
+// leastOverlapEnlargement returns the index of the entry whose rectangle
+// overlaps the others' least more when it is enlarged to include bbox, then of
+// those the one needing the least enlargement, then the one with the smallest
+// area.
+func (n *node[T]) leastOverlapEnlargement(bbox Rect) int {
+	best := 0
+	bestOverlap, bestEnlargement, bestArea := math.Inf(1), math.Inf(1), math.Inf(1)
+
+	for i, childBbox := range n.bbox {
+		enlarged := childBbox.add(bbox)
+
+		overlap := 0.0
+		for j, other := range n.bbox {
+			if j != i {
+				overlap += enlarged.overlap(other) - childBbox.overlap(other)
+			}
+		}
+
+		enlargement, area := enlarged.area()-childBbox.area(), childBbox.area()
+		if overlap < bestOverlap ||
+			(overlap == bestOverlap && enlargement < bestEnlargement) ||
+			(overlap == bestOverlap && enlargement == bestEnlargement && area < bestArea) {
+			best, bestOverlap, bestEnlargement, bestArea = i, overlap, enlargement, area
+		}
+	}
+
+	return best
+}
+
+// takeFarthest removes the count entries whose centres are farthest from the
+// centre of the node and returns them nearest first, the order in which the
+// R*-tree inserts them again.
+func (n *node[T]) takeFarthest(count int) []entry[T] {
+	cx, cy := n.bounds().centre()
+	distance := func(e entry[T]) float64 {
+		x, y := e.bbox.centre()
+		return (x-cx)*(x-cx) + (y-cy)*(y-cy)
+	}
+
+	entries := n.entries()
+	slices.SortStableFunc(entries, func(a, b entry[T]) int {
+		return cmp.Compare(distance(a), distance(b))
+	})
+
+	kept, taken := entries[:len(entries)-count], entries[len(entries)-count:]
+	*n = node[T]{}
+	for _, e := range kept {
+		n.add(e)
+	}
+
+	return taken
+}
+
+func (n *node[T]) entries() []entry[T] {
+	entries := make([]entry[T], len(n.bbox))
+	for i := range entries {
+		entries[i] = n.entry(i)
+	}
+	return entries
+}
+
+// rstarSplit divides the entries of an overflowing node between the node and
+// a new node, which it returns. Along each axis, the entries are sorted by
+// their low and then their high sides, and each way of cutting the sorted
+// entries in two that leaves at least minEntries on each side is a candidate.
+// The axis is the one whose candidates have the smallest total margin, and the
+// candidate along it with the least overlap between its two halves, then the
+// least area, is chosen.
+func (n *node[T]) rstarSplit(minEntries int) *node[T] {
+	entries := n.entries()
+
+	var axis [2][]entry[T]
+	bestMargin := math.Inf(1)
+	for _, span := range []func(Rect) (float64, float64){xSpan, ySpan} {
+		sortings := [2][]entry[T]{sortedAlong(entries, span, false), sortedAlong(entries, span, true)}
+
+		margin := 0.0
+		for _, sorted := range sortings {
+			for k := minEntries; k <= len(sorted)-minEntries; k += 1 {
+				margin += boundsOf(sorted[:k]).margin() + boundsOf(sorted[k:]).margin()
+			}
+		}
+
+		if margin < bestMargin {
+			axis, bestMargin = sortings, margin
+		}
+	}
+
+	var first, second []entry[T]
+	bestOverlap, bestArea := math.Inf(1), math.Inf(1)
+	for _, sorted := range axis {
+		for k := minEntries; k <= len(sorted)-minEntries; k += 1 {
+			bounds1, bounds2 := boundsOf(sorted[:k]), boundsOf(sorted[k:])
+			overlap, area := bounds1.overlap(bounds2), bounds1.area()+bounds2.area()
+			if overlap < bestOverlap || (overlap == bestOverlap && area < bestArea) {
+				first, second, bestOverlap, bestArea = sorted[:k], sorted[k:], overlap, area
+			}
+		}
+	}
+
+	*n = node[T]{}
+	sibling := &node[T]{}
+	for _, e := range first {
+		n.add(e)
+	}
+	for _, e := range second {
+		sibling.add(e)
+	}
+
+	return sibling
+}
+
+// sortedAlong returns the entries sorted by the low sides of their rectangles
+// along the axis given by span, or by their high sides if byHigh is set.
+func sortedAlong[T any](entries []entry[T], span func(Rect) (float64, float64), byHigh bool) []entry[T] {
+	return slices.SortedStableFunc(slices.Values(entries), func(a, b entry[T]) int {
+		aLo, aHi := span(a.bbox)
+		bLo, bHi := span(b.bbox)
+		if byHigh {
+			return cmp.Or(cmp.Compare(aHi, bHi), cmp.Compare(aLo, bLo))
+		}
+		return cmp.Or(cmp.Compare(aLo, bLo), cmp.Compare(aHi, bHi))
+	})
+}
+
+func boundsOf[T any](entries []entry[T]) Rect {
+	bounds := entries[0].bbox
+	for _, e := range entries[1:] {
+		bounds = bounds.add(e.bbox)
+	}
+	return bounds
+}
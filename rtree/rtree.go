@@ -1,23 +1,72 @@
 package rtree
 
-import "math"
-
-const (
-	maxEntries = 3
-	minEntries = maxEntries / 2
+import (
+	"fmt"
+	"math"
 )
 
+const defaultMaxEntries = 3
+
+// RTree is an R-tree as described by Guttman in "R-Trees: A Dynamic Index
+// Structure for Spatial Searching", which indexes items by their bounding
+// rectangles. Every node holds between half of Options.MaxEntries, rounded
+// down, and MaxEntries entries, except the root, and every leaf is at the same
+// level.
+//
+// The zero value is an empty tree ready to use, with the default options.
 type RTree[T any] struct {
 	root *node[T]
+	size int
+	opts Options
 }
 
-type rect struct {
+// Rect is an axis-aligned rectangle. A point is a Rect with no width or height.
+type Rect struct {
 	xMin, xMax float64
 	yMin, yMax float64
 }
 
-func (r rect) add(other rect) rect {
-	return rect{
+// NewRect returns the rectangle with the corners (x1, y1) and (x2, y2), which
+// may be given in either order.
+func NewRect(x1, y1, x2, y2 float64) Rect {
+	return Rect{
+		xMin: math.Min(x1, x2),
+		xMax: math.Max(x1, x2),
+		yMin: math.Min(y1, y2),
+		yMax: math.Max(y1, y2),
+	}
+}
+
+// Point returns the rectangle covering just the point (x, y).
+func Point(x, y float64) Rect {
+	return Rect{xMin: x, xMax: x, yMin: y, yMax: y}
+}
+
+// Min returns the corner of the rectangle with the smallest coordinates.
+func (r Rect) Min() (x, y float64) {
+	return r.xMin, r.yMin
+}
+
+// Max returns the corner of the rectangle with the largest coordinates.
+func (r Rect) Max() (x, y float64) {
+	return r.xMax, r.yMax
+}
+
+// Intersects reports whether r and other have any point in common, including
+// a point on their edges.
+func (r Rect) Intersects(other Rect) bool {
+	return r.xMin <= other.xMax && other.xMin <= r.xMax &&
+		r.yMin <= other.yMax && other.yMin <= r.yMax
+}
+
+// Contains reports whether every point of other is in r.
+func (r Rect) Contains(other Rect) bool {
+	return r.xMin <= other.xMin && other.xMax <= r.xMax &&
+		r.yMin <= other.yMin && other.yMax <= r.yMax
+}
+
+func (r Rect) add(other Rect) Rect {
+	return Rect{
 		xMin: math.Min(r.xMin, other.xMin),
 		xMax: math.Max(r.xMax, other.xMax),
 		yMin: math.Min(r.yMin, other.yMin),
@@ -25,8 +74,303 @@ func (r rect) add(other rect) rect {
 	}
 }
 
+func (r Rect) area() float64 {
+	return (r.xMax - r.xMin) * (r.yMax - r.yMin)
+}
+
+// enlargement returns how much the area of r grows by when it is extended to
+// cover other.
+func (r Rect) enlargement(other Rect) float64 {
+	return r.add(other).area() - r.area()
+}
+
+// Options configures an RTree.
+type Options struct {
+	// Split is how nodes that overflow are split. Defaults to QuadraticSplit.
+	// It is ignored by RStarInsertion, which has its own way of splitting.
+	Split SplitStrategy
+
+	// Insertion is how entries are placed in the tree. Defaults to
+	// GuttmanInsertion.
+	Insertion InsertionPolicy
+
+	// MaxEntries is the most entries a node holds, which must be at least 2.
+	// Defaults to 3. The R*-tree heuristics work best with larger nodes.
+	MaxEntries int
+}
+
+func (o *Options) withDefaults() Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.MaxEntries == 0 {
+		opts.MaxEntries = defaultMaxEntries
+	}
+	if opts.MaxEntries < 2 {
+		panic(fmt.Sprintf("rtree: MaxEntries must be at least 2, got %d", opts.MaxEntries))
+	}
+
+	return opts
+}
+
+// maxEntries returns MaxEntries, or its default in the zero value of RTree,
+// whose options are all zero.
+func (o Options) maxEntries() int {
+	if o.MaxEntries == 0 {
+		return defaultMaxEntries
+	}
+	return o.MaxEntries
+}
+
+func (o Options) minEntries() int {
+	return o.maxEntries() / 2
+}
+
+// node is a node of the tree. Entry i has the bounding rectangle bbox[i] and
+// is either the child children[i], in a node above the leaves, or the item
+// data[i], in a leaf.
 type node[T any] struct {
-	bbox     []rect
+	bbox     []Rect
 	children []*node[T]
 	data     []*T
 }
+
+func (n *node[T]) isLeaf() bool {
+	return len(n.children) == 0
+}
+
+// bounds returns the smallest rectangle that covers every entry of the node.
+func (n *node[T]) bounds() Rect {
+	bounds := n.bbox[0]
+	for _, bbox := range n.bbox[1:] {
+		bounds = bounds.add(bbox)
+	}
+	return bounds
+}
+
+// entry is an entry taken out of a node, to be added to another.
+type entry[T any] struct {
+	bbox  Rect
+	child *node[T]
+	item  *T
+}
+
+func (n *node[T]) entry(i int) entry[T] {
+	if n.isLeaf() {
+		return entry[T]{bbox: n.bbox[i], item: n.data[i]}
+	}
+	return entry[T]{bbox: n.bbox[i], child: n.children[i]}
+}
+
+func (n *node[T]) add(e entry[T]) {
+	n.bbox = append(n.bbox, e.bbox)
+	if e.child != nil {
+		n.children = append(n.children, e.child)
+	} else {
+		n.data = append(n.data, e.item)
+	}
+}
+
+// pathFrame is a step on the path from the root to a node, giving a node and
+// the index of the entry descended into from it.
+type pathFrame[T any] struct {
+	node  *node[T]
+	index int
+}
+
+func New[T any]() RTree[T] {
+	return NewWithOptions[T](nil)
+}
+
+// NewWithOptions returns an empty tree configured by opts, which may be nil
+// for the defaults.
+func NewWithOptions[T any](opts *Options) RTree[T] {
+	return RTree[T]{opts: opts.withDefaults()}
+}
+
+// Len returns the number of items in the tree.
+func (t *RTree[T]) Len() int {
+	return t.size
+}
+
+// height returns the number of levels below the root.
+func (t *RTree[T]) height() int {
+	height := 0
+	for n := t.root; !n.isLeaf(); n = n.children[0] {
+		height += 1
+	}
+	return height
+}
+
+// Insert adds item to the tree with the bounding rectangle bbox. The same item
+// may be added more than once.
+func (t *RTree[T]) Insert(bbox Rect, item *T) {
+	if t.root == nil {
+		t.root = &node[T]{}
+	}
+
+	t.insertAll([]pendingEntry[T]{{entry: entry[T]{bbox: bbox, item: item}}})
+	t.size += 1
+}
+
+// insertAll inserts each pending entry into a node at its height, along with
+// any entries that are taken out to be inserted again on the way.
+func (t *RTree[T]) insertAll(pending []pendingEntry[T]) {
+	state := insertion[T]{pending: pending}
+	for len(state.pending) > 0 {
+		p := state.pending[0]
+		state.pending = state.pending[1:]
+		t.insert(p.entry, p.height, &state)
+	}
+}
+
+// insertion is the state of a call to Insert, which may move entries that
+// were already in the tree as well as adding a new one.
+type insertion[T any] struct {
+	// reinserted records the heights at which entries have been taken out of an
+	// overflowing node to be inserted again.
+	reinserted map[int]bool
+
+	// pending holds the entries waiting to be inserted again.
+	pending []pendingEntry[T]
+}
+
+// pendingEntry is an entry waiting to be inserted into a node at height levels
+// above the leaves.
+type pendingEntry[T any] struct {
+	entry  entry[T]
+	height int
+}
+
+// insert adds e to a node at height levels above the leaves, and then deals
+// with any overflow on the way back up to the root.
+func (t *RTree[T]) insert(e entry[T], height int, state *insertion[T]) {
+	n, path := t.chooseNode(e.bbox, height)
+	n.add(e)
+	t.adjustTree(n, height, path, state)
+}
+
+// chooseNode descends from the root to the node at height levels above the
+// leaves in which to place an entry with the bounding rectangle bbox. It
+// returns the node and the path to it.
+func (t *RTree[T]) chooseNode(bbox Rect, height int) (*node[T], []pathFrame[T]) {
+	var path []pathFrame[T]
+
+	n := t.root
+	for h := t.height(); h > height; h -= 1 {
+		var best int
+		if t.opts.Insertion == RStarInsertion && h == 1 {
+			best = n.leastOverlapEnlargement(bbox)
+		} else {
+			best = n.leastEnlargement(bbox)
+		}
+
+		path = append(path, pathFrame[T]{node: n, index: best})
+		n = n.children[best]
+	}
+
+	return n, path
+}
+
+// leastEnlargement returns the index of the entry whose rectangle needs the
+// least enlargement to include bbox, and of those the one with the smallest
+// area, as in Guttman's ChooseLeaf.
+func (n *node[T]) leastEnlargement(bbox Rect) int {
+	best := 0
+	bestEnlargement, bestArea := math.Inf(1), math.Inf(1)
+
+	for i, childBbox := range n.bbox {
+		enlargement, area := childBbox.enlargement(bbox), childBbox.area()
+		if enlargement < bestEnlargement || (enlargement == bestEnlargement && area < bestArea) {
+			best, bestEnlargement, bestArea = i, enlargement, area
+		}
+	}
+
+	return best
+}
+
+// adjustTree ascends from n, at height levels above the leaves, to the root,
+// dealing with n overflowing and tightening the bounding rectangle of each node
+// on the path in its parent. Where a node is split in two, it adds the new node
+// to the parent, which may overflow in turn, and it grows a new root if the
+// root is split.
+func (t *RTree[T]) adjustTree(n *node[T], height int, path []pathFrame[T], state *insertion[T]) {
+	var split *node[T]
+	if len(n.bbox) > t.opts.maxEntries() {
+		split = t.overflow(n, height, len(path) == 0, state)
+	}
+
+	for d := len(path) - 1; d >= 0; d -= 1 {
+		parent, i := path[d].node, path[d].index
+		parent.bbox[i] = n.bounds()
+		height += 1
+
+		var parentSplit *node[T]
+		if split != nil {
+			parent.bbox = append(parent.bbox, split.bounds())
+			parent.children = append(parent.children, split)
+			if len(parent.bbox) > t.opts.maxEntries() {
+				parentSplit = t.overflow(parent, height, d == 0, state)
+			}
+		}
+
+		n, split = parent, parentSplit
+	}
+
+	if split != nil {
+		t.root = &node[T]{
+			bbox:     []Rect{n.bounds(), split.bounds()},
+			children: []*node[T]{n, split},
+		}
+	}
+}
+
+// overflow deals with n having one entry too many, returning the new node it
+// was split into, if it was. An R*-tree instead takes some entries out to be
+// inserted again the first time a node overflows at each height, other than
+// at the root.
+func (t *RTree[T]) overflow(n *node[T], height int, isRoot bool, state *insertion[T]) *node[T] {
+	if t.opts.Insertion != RStarInsertion {
+		return n.split(t.opts)
+	}
+
+	if isRoot || state.reinserted[height] {
+		return n.rstarSplit(t.opts.minEntries())
+	}
+
+	if state.reinserted == nil {
+		state.reinserted = make(map[int]bool)
+	}
+	state.reinserted[height] = true
+
+	for _, e := range n.takeFarthest(t.opts.reinsertCount()) {
+		state.pending = append(state.pending, pendingEntry[T]{entry: e, height: height})
+	}
+	return nil
+}
+
+// Search returns every item whose bounding rectangle intersects query.
+func (t *RTree[T]) Search(query Rect) []*T {
+	found := make([]*T, 0)
+	if t.root != nil {
+		found = t.root.search(query, found)
+	}
+	return found
+}
+
+func (n *node[T]) search(query Rect, found []*T) []*T {
+	for i, bbox := range n.bbox {
+		if !bbox.Intersects(query) {
+			continue
+		}
+
+		if n.isLeaf() {
+			found = append(found, n.data[i])
+		} else {
+			found = n.children[i].search(query, found)
+		}
+	}
+	return found
+}
@@ -0,0 +1,109 @@
+package rtree
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+const (
+	benchmarkItems   = 10_000
+	benchmarkQueries = 1_000
+	benchmarkExtent  = 1_000.0
+)
+
+// benchmarkRects returns n small rectangles either spread evenly over the
+// extent or gathered in a few clusters.
+func benchmarkRects(rng *rand.Rand, n int, distribution string) []Rect {
+	centres := make([][2]float64, 10)
+	for i := range centres {
+		centres[i] = [2]float64{rng.Float64() * benchmarkExtent, rng.Float64() * benchmarkExtent}
+	}
+
+	rects := make([]Rect, n)
+	for i := range rects {
+		var x, y float64
+		if distribution == "clustered" {
+			centre := centres[rng.Intn(len(centres))]
+			x = centre[0] + rng.NormFloat64()*benchmarkExtent/50
+			y = centre[1] + rng.NormFloat64()*benchmarkExtent/50
+		} else {
+			x, y = rng.Float64()*benchmarkExtent, rng.Float64()*benchmarkExtent
+		}
+		rects[i] = NewRect(x, y, x+rng.Float64()*5, y+rng.Float64()*5)
+	}
+	return rects
+}
+
+// BenchmarkSearch reports the number of nodes each search visits, which
+// depends on how well the insertion policy and split strategy keep nodes from
+// overlapping.
+func BenchmarkSearch(b *testing.B) {
+	for _, distribution := range []string{"uniform", "clustered"} {
+		for _, policy := range []struct {
+			name string
+			opts Options
+		}{
+			{"quadratic", Options{Split: QuadraticSplit, MaxEntries: 10}},
+			{"linear", Options{Split: LinearSplit, MaxEntries: 10}},
+			{"rstar", Options{Insertion: RStarInsertion, MaxEntries: 10}},
+		} {
+			b.Run(fmt.Sprintf("%s/%s", distribution, policy.name), func(b *testing.B) {
+				rng := rand.New(rand.NewSource(1))
+				rt := NewWithOptions[int](&policy.opts)
+				for i, bbox := range benchmarkRects(rng, benchmarkItems, distribution) {
+					rt.Insert(bbox, &i)
+				}
+				queries := benchmarkRects(rng, benchmarkQueries, distribution)
+				for i := range queries {
+					queries[i].xMax += benchmarkExtent / 20
+					queries[i].yMax += benchmarkExtent / 20
+				}
+
+				visits := 0
+				for _, query := range queries {
+					visits += rt.root.countVisits(query)
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i += 1 {
+					rt.Search(queries[i%len(queries)])
+				}
+
+				b.ReportMetric(float64(visits)/float64(len(queries)), "visits/query")
+			})
+		}
+	}
+}
+
+// countVisits returns the number of nodes a search for query visits.
+func (n *node[T]) countVisits(query Rect) int {
+	visits := 1
+	for i, bbox := range n.bbox {
+		if !n.isLeaf() && bbox.Intersects(query) {
+			visits += n.children[i].countVisits(query)
+		}
+	}
+	return visits
+}
+
+// BenchmarkNearest finds the ten items nearest to points spread like the
+// items, as in finding the ten restaurants nearest to someone.
+func BenchmarkNearest(b *testing.B) {
+	for _, distribution := range []string{"uniform", "clustered"} {
+		b.Run(distribution, func(b *testing.B) {
+			rng := rand.New(rand.NewSource(1))
+			rt := NewWithOptions[int](&Options{Insertion: RStarInsertion, MaxEntries: 10})
+			for i, bbox := range benchmarkRects(rng, benchmarkItems, distribution) {
+				rt.Insert(bbox, &i)
+			}
+			queries := benchmarkRects(rng, benchmarkQueries, distribution)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i += 1 {
+				x, y := queries[i%len(queries)].Min()
+				rt.Nearest(x, y, 10)
+			}
+		})
+	}
+}
@@ -5,11 +5,13 @@ import (
 )
 
 type invariantState struct {
-	leafLevel int
+	leafLevel  int
+	maxEntries int
+	minEntries int
 }
 
 func (t *RTree[T]) AssertInvariantsHold() {
-	var state invariantState
+	state := invariantState{maxEntries: t.opts.maxEntries(), minEntries: t.opts.minEntries()}
 	t.root.validateSubtree(1, &state)
 }
 
@@ -42,8 +44,8 @@ func (n *node[T]) validate(level int, state *invariantState) {
 
 	// (1) Every leaf node contalns between m and M index records unless it the root
 	if isLeaf {
-		assert("too many entries", len(n.bbox) <= maxEntries)
-		assert("too few entries", len(n.bbox) >= minEntries)
+		assert("too many entries", len(n.bbox) <= state.maxEntries)
+		assert("too few entries", isRoot || len(n.bbox) >= state.minEntries)
 		assert("len(bbox) != len(data)", len(n.bbox) == len(n.data))
 	}
 
@@ -55,8 +57,8 @@ func (n *node[T]) validate(level int, state *invariantState) {
 
 	// (3) Every non-leaf node has between m and M children unless it is the root
 	if !isLeaf {
-		assert("too many children", len(n.children) <= maxEntries)
-		assert("too few children", len(n.children) >= minEntries)
+		assert("too many children", len(n.children) <= state.maxEntries)
+		assert("too few children", isRoot || len(n.children) >= state.minEntries)
 		assert("len(bbox) != len(children)", len(n.bbox) == len(n.children))
 	}
 
@@ -65,11 +67,11 @@ func (n *node[T]) validate(level int, state *invariantState) {
 	if !isLeaf {
 		for i := 0; i < len(n.bbox); i += 1 {
 			bbox := n.bbox[i]
-			bboxes := rect{}
-			for _, childBbox := range n.children[0].bbox {
+			bboxes := n.children[i].bbox[0]
+			for _, childBbox := range n.children[i].bbox[1:] {
 				bboxes = bboxes.add(childBbox)
 			}
-			assert("bbox does not tightly contain child bboxes", bbox == bboxes) // TODO floating point comparison
+			assert("bbox does not tightly contain child bboxes", bbox == bboxes)
 		}
 	}
 
@@ -0,0 +1,69 @@
+package rtree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertionQuality(t *testing.T) {
+	policies := []struct {
+		name string
+		opts Options
+	}{
+		{"quadratic", Options{Split: QuadraticSplit, MaxEntries: 10}},
+		{"linear", Options{Split: LinearSplit, MaxEntries: 10}},
+		{"rstar", Options{Insertion: RStarInsertion, MaxEntries: 10}},
+	}
+
+	for _, distribution := range []string{"uniform", "clustered"} {
+		t.Run(distribution, func(t *testing.T) {
+			// Given
+			rects := benchmarkRects(rand.New(rand.NewSource(1)), benchmarkItems, distribution)
+
+			coverages, overlaps := make(map[string]float64), make(map[string]float64)
+			for _, policy := range policies {
+				// When
+				rt := NewWithOptions[int](&policy.opts)
+				for i, bbox := range rects {
+					rt.Insert(bbox, &i)
+				}
+
+				// Then
+				require.NoError(t, rt.CheckInvariantsHold())
+				coverage, overlap := rt.root.coverageAndOverlap()
+				t.Logf("%s: coverage %.0f, overlap %.0f", policy.name, coverage, overlap)
+				coverages[policy.name], overlaps[policy.name] = coverage, overlap
+			}
+
+			require.Less(t, overlaps["rstar"], overlaps["quadratic"])
+			require.Less(t, overlaps["rstar"], overlaps["linear"])
+			require.Less(t, coverages["rstar"], coverages["quadratic"])
+			require.Less(t, coverages["rstar"], coverages["linear"])
+		})
+	}
+}
+
+// coverageAndOverlap returns the total area of the rectangles of the entries of
+// the nodes above the leaves, and the total area shared by each pair of entries
+// in the same node.
+func (n *node[T]) coverageAndOverlap() (float64, float64) {
+	var coverage, overlap float64
+	if n.isLeaf() {
+		return coverage, overlap
+	}
+
+	for i, bbox := range n.bbox {
+		coverage += bbox.area()
+		for _, other := range n.bbox[i+1:] {
+			overlap += bbox.overlap(other)
+		}
+
+		childCoverage, childOverlap := n.children[i].coverageAndOverlap()
+		coverage += childCoverage
+		overlap += childOverlap
+	}
+
+	return coverage, overlap
+}
@@ -1 +1,329 @@
 package rtree_test
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/munckymagik/gokb/rtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTree(t *testing.T) {
+	for name, opts := range treeOptions {
+		t.Run(name, func(t *testing.T) {
+			t.Run("invariants hold after every insertion", func(t *testing.T) {
+				propFunc := func(corners []int8) bool {
+					// Given
+					rt := rtree.NewWithOptions[int](opts)
+
+					for i, bbox := range rectsFrom(corners) {
+						// When
+						rt.Insert(bbox, &i)
+
+						// Then
+						if !assert.NoError(t, rt.CheckInvariantsHold(), "after inserting %d", i) {
+							return false
+						}
+					}
+					return assert.Equal(t, len(corners)/4, rt.Len())
+				}
+
+				require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+			})
+
+			t.Run("it finds every item whose box intersects the query", func(t *testing.T) {
+				propFunc := func(corners []int8, queryCorners [4]int8) bool {
+					// Given
+					rt, items := newRTreeFrom(rectsFrom(corners), opts)
+					query := rectsFrom(queryCorners[:])[0]
+
+					// When
+					found := rt.Search(query)
+
+					// Then
+					return assert.ElementsMatch(t, searchAll(items, query), found)
+				}
+
+				require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+			})
+
+			t.Run("invariants hold after every deletion", func(t *testing.T) {
+				propFunc := func(corners []int8, seed int64) bool {
+					// Given
+					rt, items := newRTreeFrom(rectsFrom(corners), opts)
+					rand.New(rand.NewSource(seed)).Shuffle(len(items), func(i, j int) {
+						items[i], items[j] = items[j], items[i]
+					})
+
+					for i, item := range items {
+						// When
+						deleted := rt.Delete(item.bbox, item.value)
+
+						// Then
+						if !assert.True(t, deleted, "deleting %d", i) ||
+							!assert.NoError(t, rt.CheckInvariantsHold(), "after deleting %d", i) ||
+							!assert.Equal(t, len(items)-i-1, rt.Len()) ||
+							!assert.ElementsMatch(t, searchAll(items[i+1:], everywhere), rt.Search(everywhere)) {
+							return false
+						}
+					}
+					return true
+				}
+
+				require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 500}))
+			})
+
+			t.Run("it finds the k nearest items", func(t *testing.T) {
+				propFunc := func(corners []int8, x, y int8, k uint8) bool {
+					// Given
+					rt, items := newRTreeFrom(rectsFrom(corners), opts)
+					want := nearestAll(items, float64(x), float64(y))
+
+					// When
+					found := rt.Nearest(float64(x), float64(y), int(k))
+
+					// Then
+					distances := make([]float64, len(found))
+					for i, value := range found {
+						distances[i] = distanceTo(items[*value].bbox, float64(x), float64(y))
+					}
+					return assert.Equal(t, want[:min(int(k), len(want))], distances)
+				}
+
+				require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+			})
+
+			t.Run("it iterates over every item in order of distance", func(t *testing.T) {
+				propFunc := func(corners []int8, x, y int8) bool {
+					// Given
+					rt, items := newRTreeFrom(rectsFrom(corners), opts)
+
+					// When
+					found, distances := make([]*int, 0), make([]float64, 0)
+					for value, distance := range rt.NearestIter(float64(x), float64(y), nil) {
+						found = append(found, value)
+						distances = append(distances, distance)
+					}
+
+					// Then
+					all := make([]*int, len(items))
+					for i, item := range items {
+						all[i] = item.value
+					}
+					return assert.ElementsMatch(t, all, found) &&
+						assert.Equal(t, nearestAll(items, float64(x), float64(y)), distances, "distances")
+				}
+
+				require.NoError(t, quick.Check(propFunc, &quick.Config{MaxCount: 1000}))
+			})
+		})
+	}
+
+	t.Run("it stops iterating over the nearest items when asked", func(t *testing.T) {
+		// Given
+		rt, items := newRTreeFrom(rectsFrom([]int8{
+			0, 0, 1, 1,
+			5, 5, 6, 6,
+			2, 2, 3, 3,
+			-9, -9, -8, -8,
+		}), nil)
+
+		// When
+		var found []*int
+		for value := range rt.NearestIter(0, 0, nil) {
+			found = append(found, value)
+			if len(found) == 2 {
+				break
+			}
+		}
+
+		// Then
+		require.Equal(t, []*int{items[0].value, items[2].value}, found)
+	})
+
+	t.Run("it measures the distance to items that are not points with the given function", func(t *testing.T) {
+		// Given a circle whose box covers the query point but which is further
+		// from it than a point beside it
+		type circle struct{ x, y, radius float64 }
+		rt := rtree.New[circle]()
+		ring := &circle{x: 10, y: 10, radius: 10}
+		dot := &circle{x: 3, y: 0}
+		for _, c := range []*circle{ring, dot} {
+			rt.Insert(rtree.NewRect(c.x-c.radius, c.y-c.radius, c.x+c.radius, c.y+c.radius), c)
+		}
+		toCircle := func(x, y float64, _ rtree.Rect, c *circle) float64 {
+			return math.Max(0, math.Hypot(x-c.x, y-c.y)-c.radius)
+		}
+
+		// When
+		var found []*circle
+		var distances []float64
+		for c, distance := range rt.NearestIter(0, 0, toCircle) {
+			found = append(found, c)
+			distances = append(distances, distance)
+		}
+
+		// Then
+		require.Equal(t, []*circle{dot, ring}, rt.NearestFunc(0, 0, 2, toCircle))
+		require.Equal(t, []*circle{dot}, rt.NearestFunc(0, 0, 1, toCircle))
+		require.Equal(t, []*circle{dot, ring}, found)
+		require.InDeltaSlice(t, []float64{3, 10*math.Sqrt2 - 10}, distances, 1e-9)
+	})
+
+	t.Run("it finds no nearest items when none are asked for", func(t *testing.T) {
+		// Given
+		rt, _ := newRTreeFrom(rectsFrom([]int8{0, 0, 1, 1}), nil)
+
+		// When
+		found := rt.Nearest(0, 0, 0)
+
+		// Then
+		require.NotNil(t, found)
+		require.Empty(t, found)
+	})
+
+	t.Run("it only deletes the item it was given", func(t *testing.T) {
+		// Given
+		bbox := rtree.NewRect(0, 0, 1, 1)
+		rt, items := newRTreeFrom([]rtree.Rect{bbox, bbox}, nil)
+		other := 0
+
+		// When
+		deletedOther := rt.Delete(bbox, &other)
+		deletedElsewhere := rt.Delete(rtree.NewRect(0, 0, 2, 2), items[0].value)
+		deleted := rt.Delete(bbox, items[0].value)
+		deletedAgain := rt.Delete(bbox, items[0].value)
+
+		// Then
+		require.False(t, deletedOther)
+		require.False(t, deletedElsewhere)
+		require.True(t, deleted)
+		require.False(t, deletedAgain)
+		require.Equal(t, []*int{items[1].value}, rt.Search(bbox))
+	})
+
+	t.Run("a tree can be filled again after deleting everything", func(t *testing.T) {
+		// Given
+		rt, items := newRTreeFrom(rectsFrom([]int8{0, 0, 1, 1, 2, 2, 3, 3}), nil)
+		for _, item := range items {
+			rt.Delete(item.bbox, item.value)
+		}
+
+		// When
+		value := 0
+		rt.Insert(rtree.Point(1, 1), &value)
+
+		// Then
+		require.Equal(t, 1, rt.Len())
+		require.Equal(t, []*int{&value}, rt.Search(everywhere))
+		require.NoError(t, rt.CheckInvariantsHold())
+	})
+
+	t.Run("it finds items that only touch the query", func(t *testing.T) {
+		// Given
+		rt, items := newRTreeFrom([]rtree.Rect{
+			rtree.NewRect(0, 0, 1, 1),
+			rtree.NewRect(2, 2, 3, 3),
+			rtree.Point(5, 5),
+		}, nil)
+
+		// When
+		found := rt.Search(rtree.NewRect(1, 1, 2, 2))
+
+		// Then
+		require.ElementsMatch(t, []*int{items[0].value, items[1].value}, found)
+	})
+
+	t.Run("nodes must have room for at least two entries", func(t *testing.T) {
+		require.Panics(t, func() { rtree.NewWithOptions[int](&rtree.Options{MaxEntries: 1}) })
+	})
+
+	t.Run("an empty tree finds nothing", func(t *testing.T) {
+		// Given
+		var rt rtree.RTree[int]
+
+		// When
+		found := rt.Search(rtree.NewRect(-1, -1, 1, 1))
+
+		// Then
+		require.Empty(t, found)
+		require.NoError(t, rt.CheckInvariantsHold())
+		require.False(t, rt.Delete(rtree.Point(0, 0), new(int)))
+		require.Empty(t, rt.Nearest(0, 0, 1))
+	})
+}
+
+var treeOptions = map[string]*rtree.Options{
+	"quadratic": {Split: rtree.QuadraticSplit},
+	"linear":    {Split: rtree.LinearSplit},
+	"rstar":     {Insertion: rtree.RStarInsertion},
+
+	"quadratic/M=8": {Split: rtree.QuadraticSplit, MaxEntries: 8},
+	"linear/M=8":    {Split: rtree.LinearSplit, MaxEntries: 8},
+	"rstar/M=8":     {Insertion: rtree.RStarInsertion, MaxEntries: 8},
+}
+
+// everywhere covers every rectangle made by rectsFrom.
+var everywhere = rtree.NewRect(-128, -128, 127, 127)
+
+type indexedItem struct {
+	bbox  rtree.Rect
+	value *int
+}
+
+// rectsFrom makes a rectangle from each group of four coordinates, ignoring
+// any left over.
+func rectsFrom(corners []int8) []rtree.Rect {
+	rects := make([]rtree.Rect, 0, len(corners)/4)
+	for i := 0; i+3 < len(corners); i += 4 {
+		rects = append(rects, rtree.NewRect(
+			float64(corners[i]), float64(corners[i+1]),
+			float64(corners[i+2]), float64(corners[i+3]),
+		))
+	}
+	return rects
+}
+
+func newRTreeFrom(rects []rtree.Rect, opts *rtree.Options) (rtree.RTree[int], []indexedItem) {
+	rt := rtree.NewWithOptions[int](opts)
+	items := make([]indexedItem, len(rects))
+	for i, bbox := range rects {
+		items[i] = indexedItem{bbox: bbox, value: &i}
+		rt.Insert(bbox, items[i].value)
+	}
+	return rt, items
+}
+
+// searchAll returns the items whose boxes intersect query by checking every one.
+func searchAll(items []indexedItem, query rtree.Rect) []*int {
+	found := make([]*int, 0)
+	for _, item := range items {
+		if item.bbox.Intersects(query) {
+			found = append(found, item.value)
+		}
+	}
+	return found
+}
+
+// nearestAll returns the distance from (x, y) to every item, nearest first,
+// by checking every one.
+func nearestAll(items []indexedItem, x, y float64) []float64 {
+	distances := make([]float64, len(items))
+	for i, item := range items {
+		distances[i] = distanceTo(item.bbox, x, y)
+	}
+	slices.Sort(distances)
+	return distances
+}
+
+func distanceTo(bbox rtree.Rect, x, y float64) float64 {
+	xMin, yMin := bbox.Min()
+	xMax, yMax := bbox.Max()
+	dx := math.Max(0, math.Max(xMin-x, x-xMax))
+	dy := math.Max(0, math.Max(yMin-y, y-yMax))
+	return math.Hypot(dx, dy)
+}
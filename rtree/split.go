@@ -0,0 +1,191 @@
+package rtree
+
+import (
+	"math"
+	"slices"
+)
+
+// SplitStrategy chooses how the entries of a node that overflows are divided
+// between it and a new node.
+type SplitStrategy int
+
+const (
+	// QuadraticSplit is Guttman's quadratic algorithm. It looks at every pair
+	// of entries to find the two that are worst together, and at every
+	// remaining entry each time it assigns one, so it takes time quadratic in
+	// the number of entries and gives nodes that overlap less.
+	QuadraticSplit SplitStrategy = iota
+
+	// LinearSplit is Guttman's linear algorithm. It picks the two entries that
+	// are furthest apart along either axis, and then assigns the rest in any
+	// order, so it takes linear time at the cost of nodes that overlap more.
+	LinearSplit
+)
+
+// split divides the entries of an overflowing node between the node and a new
+// node, which it returns. Each node starts with one of a pair of seed entries
+// chosen by the split strategy, and then each entry the strategy picks next goes to
+// the node whose rectangle it enlarges least.
+func (n *node[T]) split(opts Options) *node[T] {
+	full := *n
+	*n = node[T]{}
+	sibling := &node[T]{}
+
+	var seed1, seed2 int
+	var pickNext func(bbox []Rect, remaining []int, bounds1, bounds2 Rect) int
+	switch opts.Split {
+	case LinearSplit:
+		seed1, seed2 = linearPickSeeds(full.bbox)
+		pickNext = linearPickNext
+	default:
+		seed1, seed2 = quadraticPickSeeds(full.bbox)
+		pickNext = quadraticPickNext
+	}
+
+	n.add(full.entry(seed1))
+	sibling.add(full.entry(seed2))
+	bounds1, bounds2 := full.bbox[seed1], full.bbox[seed2]
+
+	remaining := make([]int, 0, len(full.bbox)-2)
+	for i := range full.bbox {
+		if i != seed1 && i != seed2 {
+			remaining = append(remaining, i)
+		}
+	}
+
+	for len(remaining) > 0 {
+		// When one node needs all the remaining entries to reach the minimum,
+		// it gets them.
+		if len(n.bbox)+len(remaining) <= opts.minEntries() {
+			for _, i := range remaining {
+				n.add(full.entry(i))
+			}
+			break
+		}
+		if len(sibling.bbox)+len(remaining) <= opts.minEntries() {
+			for _, i := range remaining {
+				sibling.add(full.entry(i))
+			}
+			break
+		}
+
+		next := pickNext(full.bbox, remaining, bounds1, bounds2)
+		i := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+
+		if prefersFirst(full.bbox[i], bounds1, bounds2, len(n.bbox), len(sibling.bbox)) {
+			n.add(full.entry(i))
+			bounds1 = bounds1.add(full.bbox[i])
+		} else {
+			sibling.add(full.entry(i))
+			bounds2 = bounds2.add(full.bbox[i])
+		}
+	}
+
+	return sibling
+}
+
+// quadraticPickSeeds returns the pair of entries whose covering rectangle has
+// the most area left over after taking away their own areas.
+func quadraticPickSeeds(bbox []Rect) (int, int) {
+	seed1, seed2 := 0, 1
+	worstWaste := math.Inf(-1)
+
+	for i := 0; i < len(bbox); i += 1 {
+		for j := i + 1; j < len(bbox); j += 1 {
+			waste := bbox[i].add(bbox[j]).area() - bbox[i].area() - bbox[j].area()
+			if waste > worstWaste {
+				seed1, seed2, worstWaste = i, j, waste
+			}
+		}
+	}
+
+	return seed1, seed2
+}
+
+// quadraticPickNext returns the index in remaining of the entry with the
+// greatest difference between the enlargements of the two groups needed to
+// include it.
+func quadraticPickNext(bbox []Rect, remaining []int, bounds1, bounds2 Rect) int {
+	next := 0
+	greatest := math.Inf(-1)
+
+	for k, i := range remaining {
+		difference := math.Abs(bounds1.enlargement(bbox[i]) - bounds2.enlargement(bbox[i]))
+		if difference > greatest {
+			next, greatest = k, difference
+		}
+	}
+
+	return next
+}
+
+// linearPickSeeds returns the pair of entries that are furthest apart along
+// either axis: the entry whose low side is highest and, of the others, the one
+// whose high side is lowest, with their separation measured as a fraction of
+// the width of all the entries along that axis.
+func linearPickSeeds(bbox []Rect) (int, int) {
+	x1, x2, xSeparation := linearSeedsAlong(bbox, xSpan)
+	y1, y2, ySeparation := linearSeedsAlong(bbox, ySpan)
+
+	if ySeparation > xSeparation {
+		return y1, y2
+	}
+	return x1, x2
+}
+
+// linearSeedsAlong returns the linear seeds along the axis whose extent in each
+// rectangle is given by span, and their normalised separation.
+func linearSeedsAlong(bbox []Rect, span func(Rect) (float64, float64)) (int, int, float64) {
+	lo, hi := make([]float64, len(bbox)), make([]float64, len(bbox))
+	for i, r := range bbox {
+		lo[i], hi[i] = span(r)
+	}
+
+	highestLow := 0
+	for i := range lo {
+		if lo[i] > lo[highestLow] {
+			highestLow = i
+		}
+	}
+
+	lowestHigh := -1
+	for i := range hi {
+		if i != highestLow && (lowestHigh < 0 || hi[i] < hi[lowestHigh]) {
+			lowestHigh = i
+		}
+	}
+
+	separation := lo[highestLow] - hi[lowestHigh]
+	if width := slices.Max(hi) - slices.Min(lo); width > 0 {
+		separation /= width
+	}
+
+	return lowestHigh, highestLow, separation
+}
+
+// xSpan and ySpan return the extent of a rectangle along each axis.
+func xSpan(r Rect) (float64, float64) { return r.xMin, r.xMax }
+func ySpan(r Rect) (float64, float64) { return r.yMin, r.yMax }
+
+// linearPickNext takes the remaining entries in any order, so takes the first.
+func linearPickNext([]Rect, []int, Rect, Rect) int {
+	return 0
+}
+
+// prefersFirst reports whether an entry with the bounding rectangle bbox
+// should join the first group rather than the second: the one it enlarges
+// least, then the one with the smaller area, then the one with fewer entries.
+func prefersFirst(bbox, bounds1, bounds2 Rect, len1, len2 int) bool {
+	enlargement1, enlargement2 := bounds1.enlargement(bbox), bounds2.enlargement(bbox)
+	if enlargement1 != enlargement2 {
+		return enlargement1 < enlargement2
+	}
+
+	area1, area2 := bounds1.area(), bounds2.area()
+	if area1 != area2 {
+		return area1 < area2
+	}
+
+	return len1 <= len2
+}